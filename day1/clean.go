@@ -0,0 +1,288 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Class is what the cleaner decided a reading was.
+type Class int
+
+const (
+	Valid Class = iota
+	Malformed
+	Outlier
+)
+
+func (c Class) String() string {
+	switch c {
+	case Valid:
+		return "valid"
+	case Malformed:
+		return "malformed"
+	case Outlier:
+		return "outlier"
+	}
+	return fmt.Sprintf("Class(%d)", int(c))
+}
+
+// Fix is what the cleaner does with readings that are not valid.
+type Fix int
+
+const (
+	// Keep passes outliers through unchanged. Malformed readings have no value,
+	// so they are always dropped.
+	Keep Fix = iota
+	// Drop removes bad readings.
+	Drop
+	// Interpolate replaces bad readings with values on a straight line between
+	// the valid readings either side of them.
+	Interpolate
+)
+
+var fixes = map[string]Fix{
+	"keep":        Keep,
+	"drop":        Drop,
+	"interpolate": Interpolate,
+}
+
+// Anomaly is a reading that was not valid, and what was done about it.
+type Anomaly struct {
+	Line   int
+	Text   string
+	Class  Class
+	Reason string
+	Action string
+}
+
+// Cleaner sits in front of another Processor and classifies each reading as
+// valid, malformed or an outlier before passing it on.
+//
+// A reading is an outlier if it is negative, if it differs from the last valid
+// reading by more than MaxDelta, or if that change is more than ZScore standard
+// deviations from the mean change seen so far. Zero turns a threshold off. The
+// running statistics take constant memory, so huge logs can be cleaned as they
+// stream.
+//
+// A jump can also be a real change of level, which every later reading would
+// otherwise be measured against. Once Reanchor outliers in a row are no jump
+// from each other, the last of them is taken as valid and becomes the reading
+// the next are compared with. So with -maxdelta 50,
+//
+//	200 210 900 905 910 915
+//
+// flags 900 and 905 as outliers and takes 910 as a level shift, after which
+// 915 is valid.
+type Cleaner struct {
+	next     Processor
+	Fix      Fix
+	MaxDelta float64
+	ZScore   float64
+	// Reanchor is how many consistent outliers in a row are taken as a level
+	// shift, or zero to never re-anchor.
+	Reanchor int
+	// MaxListed limits how many anomalies are kept for the report; the counts
+	// are always complete.
+	MaxListed int
+	// MaxPending limits how many bad readings in a row are held waiting to be
+	// interpolated. Past that they are dropped, so memory stays bounded.
+	MaxPending int
+
+	line   int
+	counts [3]int
+	// Anomalies lists the first MaxListed readings that were not valid.
+	Anomalies  []Anomaly
+	Dropped    int
+	Replaced   int
+	Unresolved int
+	Shifts     int
+	last       float64
+	haveLast   bool
+	// run counts the outliers in a row that are no jump from each other, the
+	// last of which was runLast
+	run        int
+	runLast    float64
+	changes    int
+	changeMean float64
+	changeM2   float64
+	// pendingBad holds the lines of bad readings waiting to be interpolated
+	pendingBad []int
+}
+
+// zScoreWarmup is how many changes must be seen before the z-score test is
+// trusted.
+const zScoreWarmup = 10
+
+func NewCleaner(next Processor, fix Fix) *Cleaner {
+	return &Cleaner{
+		next:       next,
+		Fix:        fix,
+		Reanchor:   3,
+		MaxListed:  20,
+		MaxPending: 1000,
+	}
+}
+
+// classify decides what the reading s is. A valid reading with a reason is a
+// level shift.
+func (c *Cleaner) classify(s string) (float64, Class, string) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, Malformed, "not a number"
+	}
+	if v < 0 {
+		c.run = 0
+		return v, Outlier, "negative depth"
+	}
+	if !c.haveLast {
+		return v, Valid, ""
+	}
+	reason := c.jump(v - c.last)
+	if reason == "" {
+		c.run = 0
+		return v, Valid, ""
+	}
+	if c.run > 0 && c.jump(v-c.runLast) == "" {
+		c.run++
+	} else {
+		c.run = 1
+	}
+	c.runLast = v
+	if c.Reanchor > 0 && c.run >= c.Reanchor {
+		c.run = 0
+		return v, Valid, fmt.Sprintf("level shift of %g", v-c.last)
+	}
+	return v, Outlier, reason
+}
+
+// jump says why a change of delta from one reading to the next is too big, or
+// returns "" if it isn't.
+func (c *Cleaner) jump(delta float64) string {
+	if c.MaxDelta > 0 && math.Abs(delta) > c.MaxDelta {
+		return fmt.Sprintf("jump of %g", delta)
+	}
+	if c.ZScore > 0 && c.changes >= zScoreWarmup {
+		sd := math.Sqrt(c.changeM2 / float64(c.changes-1))
+		if sd > 0 {
+			if z := (delta - c.changeMean) / sd; math.Abs(z) > c.ZScore {
+				return fmt.Sprintf("jump of %g is %.1f standard deviations", delta, z)
+			}
+		}
+	}
+	return ""
+}
+
+func (c *Cleaner) Process(s string) {
+	c.line++
+	v, class, reason := c.classify(s)
+	c.counts[class]++
+	if class == Valid {
+		if reason != "" {
+			// a level shift isn't a change to learn from
+			c.Shifts++
+			c.haveLast = false
+		}
+		c.accept(v)
+		return
+	}
+
+	var action string
+	switch {
+	case class == Outlier && c.Fix == Keep:
+		action = "kept"
+		c.next.Process(formatDepth(v))
+	case c.Fix == Interpolate && c.haveLast:
+		if len(c.pendingBad) == c.MaxPending {
+			c.dropPending("dropped, too many bad readings in a row")
+		}
+		action = "interpolated"
+		c.pendingBad = append(c.pendingBad, c.line)
+	default:
+		action = "dropped"
+		c.Dropped++
+	}
+	if len(c.Anomalies) < c.MaxListed {
+		c.Anomalies = append(c.Anomalies, Anomaly{
+			Line:   c.line,
+			Text:   s,
+			Class:  class,
+			Reason: reason,
+			Action: action,
+		})
+	}
+}
+
+// accept passes on a valid reading, first filling in any bad readings waiting
+// to be interpolated.
+func (c *Cleaner) accept(v float64) {
+	if len(c.pendingBad) > 0 {
+		gap := float64(len(c.pendingBad) + 1)
+		for i := range c.pendingBad {
+			frac := float64(i+1) / gap
+			c.next.Process(formatDepth(c.last + frac*(v-c.last)))
+			c.Replaced++
+		}
+		c.pendingBad = c.pendingBad[:0]
+	}
+	if c.haveLast {
+		// Welford's running mean and variance of the changes
+		delta := v - c.last
+		c.changes++
+		d := delta - c.changeMean
+		c.changeMean += d / float64(c.changes)
+		c.changeM2 += d * (delta - c.changeMean)
+	}
+	c.last = v
+	c.haveLast = true
+	c.next.Process(formatDepth(v))
+}
+
+func formatDepth(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// Finish must be called at the end of the input. Bad readings at the very end
+// have no valid reading after them to interpolate towards, so they are dropped.
+func (c *Cleaner) Finish() {
+	c.dropPending("dropped, nothing to interpolate towards")
+}
+
+// dropPending gives up on the bad readings waiting to be interpolated.
+func (c *Cleaner) dropPending(action string) {
+	c.Unresolved += len(c.pendingBad)
+	c.Dropped += len(c.pendingBad)
+	for _, line := range c.pendingBad {
+		for i := range c.Anomalies {
+			if c.Anomalies[i].Line == line {
+				c.Anomalies[i].Action = action
+			}
+		}
+	}
+	c.pendingBad = c.pendingBad[:0]
+}
+
+func (c *Cleaner) Result() int {
+	return c.next.Result()
+}
+
+func (c *Cleaner) Print(w io.Writer) {
+	fmt.Fprintf(w, "%d readings: %d valid, %d malformed, %d outliers\n",
+		c.line, c.counts[Valid], c.counts[Malformed], c.counts[Outlier])
+	fmt.Fprintf(w, "%d dropped, %d interpolated", c.Dropped, c.Replaced)
+	if c.Unresolved > 0 {
+		fmt.Fprintf(w, " (%d couldn't be interpolated)", c.Unresolved)
+	}
+	if c.Shifts > 0 {
+		fmt.Fprintf(w, ", %d level shifts", c.Shifts)
+	}
+	fmt.Fprintln(w)
+	for _, a := range c.Anomalies {
+		fmt.Fprintf(w, "  line %d: %q %s, %s: %s\n", a.Line, a.Text, a.Class, a.Reason, a.Action)
+	}
+	if listed := c.counts[Malformed] + c.counts[Outlier]; listed > len(c.Anomalies) {
+		fmt.Fprintf(w, "  ... and %d more\n", listed-len(c.Anomalies))
+	}
+}
@@ -1,10 +1,16 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
+	"math"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
+
+	"advent-2021/input"
 )
 
 /*
@@ -26,8 +32,101 @@ In this example, there are 7 measurements that are larger than the previous meas
 
 */
 func main() {
-	process(&Part1{})
-	process(&Part2{})
+	flag.Parse()
+	if flag.Arg(0) == "window" {
+		fs := flag.NewFlagSet("window", flag.ExitOnError)
+		size := fs.Int("n", 3, "number of readings in each window")
+		agg := fs.String("agg", "sum", "how to combine a window: sum, mean or median")
+		fs.Parse(flag.Args()[1:])
+		aggregate, ok := aggregates[*agg]
+		if !ok || *size < 1 {
+			fs.Usage()
+			os.Exit(2)
+		}
+		process(NewWindow(*size, aggregate))
+		return
+	}
+	if flag.Arg(0) == "clean" {
+		fs := flag.NewFlagSet("clean", flag.ExitOnError)
+		size := fs.Int("n", 1, "number of readings in each window")
+		fix := fs.String("fix", "keep", "what to do with bad readings: keep, drop or interpolate")
+		maxDelta := fs.Float64("maxdelta", 0, "treat a change bigger than this as an outlier, 0 for no limit")
+		zScore := fs.Float64("z", 0, "treat a change more than this many standard deviations out as an outlier, 0 for no limit")
+		reanchor := fs.Int("reanchor", 3, "take this many outliers in a row at a new level as a level shift, 0 to never")
+		fs.Parse(flag.Args()[1:])
+		f, ok := fixes[*fix]
+		if !ok || *size < 1 || *reanchor < 0 {
+			fs.Usage()
+			os.Exit(2)
+		}
+		c := NewCleaner(NewWindow(*size, Sum), f)
+		c.MaxDelta = *maxDelta
+		c.ZScore = *zScore
+		c.Reanchor = *reanchor
+		load(c)
+		c.Finish()
+		c.Print(os.Stdout)
+		fmt.Println(c.Result())
+		return
+	}
+	if flag.Arg(0) == "timed" {
+		fs := flag.NewFlagSet("timed", flag.ExitOnError)
+		bucket := fs.Duration("bucket", 30*time.Second, "average the readings in buckets of this long")
+		window := fs.Duration("window", 0, "compare windows of this long, a multiple of -bucket (default one bucket)")
+		agg := fs.String("agg", "mean", "how to combine the buckets in a window: sum, mean or median")
+		report := fs.Bool("report", false, "print a full report rather than just the number of increases")
+		fs.Parse(flag.Args()[1:])
+		if *window == 0 {
+			*window = *bucket
+		}
+		aggregate, ok := aggregates[*agg]
+		if !ok || *bucket <= 0 || *window%*bucket != 0 {
+			fs.Usage()
+			os.Exit(2)
+		}
+		size := int(*window / *bucket)
+		var next Processor = NewWindow(size, aggregate)
+		if *report {
+			next = NewReport(size, aggregate)
+		}
+		r := NewResampler(next, *bucket)
+		load(r)
+		r.Finish()
+		r.Print(os.Stdout)
+		if *report {
+			next.(*Report).Print(os.Stdout)
+			return
+		}
+		fmt.Println(r.Result())
+		return
+	}
+	if flag.Arg(0) == "report" {
+		fs := flag.NewFlagSet("report", flag.ExitOnError)
+		sizes := fs.String("n", "1,3", "comma separated window sizes to report on")
+		agg := fs.String("agg", "sum", "how to combine a window: sum, mean or median")
+		fs.Parse(flag.Args()[1:])
+		aggregate, ok := aggregates[*agg]
+		if !ok {
+			fs.Usage()
+			os.Exit(2)
+		}
+		var rs reports
+		for _, v := range strings.Split(*sizes, ",") {
+			size, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil || size < 1 {
+				fs.Usage()
+				os.Exit(2)
+			}
+			rs = append(rs, NewReport(size, aggregate))
+		}
+		load(rs)
+		for _, r := range rs {
+			r.Print(os.Stdout)
+		}
+		return
+	}
+	process(NewWindow(1, Sum))
+	process(NewWindow(3, Sum))
 }
 
 type Processor interface {
@@ -35,73 +134,148 @@ type Processor interface {
 	Result() int
 }
 
-type Part1 struct {
-	count int
-	last  int
-}
+// Aggregate combines the readings in a window into the value that is compared
+// with the previous window. The readings are not in any particular order.
+type Aggregate func(readings []float64) float64
 
-func (p *Part1) Process(s string) {
-	i, err := strconv.Atoi(s)
-	if err != nil {
-		panic(err)
+func Sum(readings []float64) float64 {
+	total := 0.0
+	for _, v := range readings {
+		total += v
 	}
-	if p.last > 0 && i > p.last {
-		p.count++
+	return total
+}
+
+func Mean(readings []float64) float64 {
+	return Sum(readings) / float64(len(readings))
+}
+
+func Median(readings []float64) float64 {
+	sorted := append([]float64(nil), readings...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
 	}
-	p.last = i
+	return sorted[mid]
 }
 
-func (p *Part1) Result() int {
-	return p.count
+var aggregates = map[string]Aggregate{
+	"sum":    Sum,
+	"mean":   Mean,
+	"median": Median,
 }
 
-func process(p Processor) {
-	f, err := os.Open("./day1/input.txt")
-	if err != nil {
-		panic(err)
+// slider holds the last few readings and combines them once there are enough.
+type slider struct {
+	agg      Aggregate
+	readings []float64
+	// filled is how many readings have been seen, up to the window size; next
+	// is where the next reading goes
+	filled int
+	next   int
+}
+
+func newSlider(size int, agg Aggregate) slider {
+	return slider{
+		agg:      agg,
+		readings: make([]float64, size),
 	}
-	defer f.Close()
-	scanner := bufio.NewScanner(f)
+}
 
-	scanner.Split(bufio.ScanLines)
+// reset forgets the readings, so the next window starts empty.
+func (s *slider) reset() {
+	s.filled = 0
+	s.next = 0
+}
 
-	for scanner.Scan() {
-		p.Process(scanner.Text())
+// add records a reading and returns the aggregate of the current window, or
+// false if there have not yet been enough readings to fill it.
+func (s *slider) add(v float64) (float64, bool) {
+	s.readings[s.next] = v
+	s.next = (s.next + 1) % len(s.readings)
+	if s.filled < len(s.readings) {
+		s.filled++
+		if s.filled < len(s.readings) {
+			return 0, false
+		}
 	}
-	fmt.Println(p.Result())
+	return s.agg(s.readings), true
 }
 
-type Part2 struct {
+// Window counts how many times the aggregate of a sliding window of readings
+// is larger than the aggregate of the window before it. Part 1 is a window of
+// one reading and part 2 is the sum of a window of three.
+type Window struct {
+	slider
+	seen  bool
+	last  float64
 	count int
-	last  [3]int
 }
 
-func (p *Part2) Process(s string) {
-	i, err := strconv.Atoi(s)
-	if err != nil {
-		panic(err)
+func NewWindow(size int, agg Aggregate) *Window {
+	return &Window{
+		slider: newSlider(size, agg),
 	}
-	if p.last[0] == 0 {
-		p.last[0] = i
+}
+
+// Gapper is a Processor that can be told where readings are missing, such as
+// empty time buckets. Windows are never compared across a gap.
+type Gapper interface {
+	Gap()
+}
+
+func (w *Window) Gap() {
+	w.reset()
+	w.seen = false
+}
+
+func (w *Window) Process(s string) {
+	cur, ok := w.add(parseDepth(s))
+	if !ok {
 		return
 	}
-	if p.last[1] == 0 {
-		p.last[1] = i
-		return
+	if w.seen && cur > w.last {
+		w.count++
 	}
-	if p.last[2] == 0 {
-		p.last[2] = i
-		return
+	w.seen = true
+	w.last = cur
+}
+
+// parseDepth reads a single depth. Depths are usually whole numbers, but
+// resampled readings can be averages.
+func parseDepth(s string) float64 {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		panic(err)
 	}
-	curSum := p.last[0] + p.last[1] + p.last[2]
-	if curSum < p.last[1]+p.last[2]+i {
-		p.count++
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		panic(fmt.Errorf("depth %q is not a finite number", s))
 	}
-	p.last[0] = p.last[1]
-	p.last[1] = p.last[2]
-	p.last[2] = i
+	return v
+}
+
+func (w *Window) Result() int {
+	return w.count
 }
 
-func (p *Part2) Result() int {
-	return p.count
+func process(p Processor) {
+	load(p)
+	fmt.Println(p.Result())
+}
+
+func load(p Processor) {
+	f, err := os.Open("./day1/input.txt")
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	scanner := input.Lines(f, "./day1/input.txt")
+
+	for scanner.Scan() {
+		p.Process(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		panic(err)
+	}
 }
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// Streak is a run of consecutive windows, from window Start to window End
+// inclusive. Window k covers readings k through k+size-1, unless there was a
+// gap before it, after which windows are numbered on from the last full one.
+type Streak struct {
+	Start int
+	End   int
+}
+
+func (s Streak) Len() int {
+	if s.End == s.Start {
+		return 0
+	}
+	return s.End - s.Start + 1
+}
+
+func (s Streak) String() string {
+	if s.Len() == 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%d windows, %d to %d", s.Len(), s.Start, s.End)
+}
+
+// Report gathers statistics about the windows of a sonar sweep as the readings
+// stream past, without keeping more than one window of them.
+type Report struct {
+	slider
+	// windows is the number of full windows seen so far
+	windows int
+	// last is the previous window, if there is one since the last gap, and
+	// fresh is true until it has been compared with another
+	last     float64
+	haveLast bool
+	fresh    bool
+	// dir is the sign of the last change, and start is the window where the
+	// current run of changes in that direction began
+	dir   int
+	start int
+
+	Increases int
+	Decreases int
+	Unchanged int
+	// FlatRuns is the number of runs of two or more equal windows
+	FlatRuns    int
+	LongestRise Streak
+	LongestFall Streak
+	LongestFlat Streak
+	// MaxDescent is the biggest drop from one window to the next, ending at
+	// window MaxDescentAt
+	MaxDescent   float64
+	MaxDescentAt int
+	Min          float64
+	MinAt        int
+	Max          float64
+	MaxAt        int
+	sum          float64
+}
+
+func NewReport(size int, agg Aggregate) *Report {
+	return &Report{
+		slider: newSlider(size, agg),
+		Min:    math.Inf(1),
+		Max:    math.Inf(-1),
+	}
+}
+
+func (r *Report) Gap() {
+	r.reset()
+	r.haveLast = false
+}
+
+func (r *Report) Process(s string) {
+	cur, ok := r.add(parseDepth(s))
+	if !ok {
+		return
+	}
+	r.observe(cur)
+}
+
+// observe records the value of the next full window.
+func (r *Report) observe(cur float64) {
+	k := r.windows
+	r.windows++
+	r.sum += cur
+	if cur < r.Min {
+		r.Min, r.MinAt = cur, k
+	}
+	if cur > r.Max {
+		r.Max, r.MaxAt = cur, k
+	}
+	if !r.haveLast {
+		r.last = cur
+		r.haveLast = true
+		r.fresh = true
+		return
+	}
+
+	dir := 0
+	switch {
+	case cur > r.last:
+		dir = 1
+		r.Increases++
+	case cur < r.last:
+		dir = -1
+		r.Decreases++
+		if drop := r.last - cur; drop > r.MaxDescent {
+			r.MaxDescent, r.MaxDescentAt = drop, k
+		}
+	default:
+		r.Unchanged++
+	}
+	if r.fresh || dir != r.dir {
+		r.start = k - 1
+		if dir == 0 {
+			r.FlatRuns++
+		}
+	}
+	r.dir = dir
+	r.last = cur
+	r.fresh = false
+
+	run := Streak{Start: r.start, End: k}
+	longest := &r.LongestFlat
+	switch dir {
+	case 1:
+		longest = &r.LongestRise
+	case -1:
+		longest = &r.LongestFall
+	}
+	if run.Len() > longest.Len() {
+		*longest = run
+	}
+}
+
+// Result is the number of increases, the same as Window gives.
+func (r *Report) Result() int {
+	return r.Increases
+}
+
+func (r *Report) Mean() float64 {
+	if r.windows == 0 {
+		return 0
+	}
+	return r.sum / float64(r.windows)
+}
+
+func (r *Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "window of %d: %d windows\n", len(r.readings), r.windows)
+	if r.windows == 0 {
+		return
+	}
+	fmt.Fprintf(w, "  increases:       %d\n", r.Increases)
+	fmt.Fprintf(w, "  decreases:       %d\n", r.Decreases)
+	fmt.Fprintf(w, "  unchanged:       %d, in %d runs\n", r.Unchanged, r.FlatRuns)
+	fmt.Fprintf(w, "  longest rise:    %v\n", r.LongestRise)
+	fmt.Fprintf(w, "  longest fall:    %v\n", r.LongestFall)
+	fmt.Fprintf(w, "  longest flat:    %v\n", r.LongestFlat)
+	if r.Decreases > 0 {
+		fmt.Fprintf(w, "  biggest descent: %g, into window %d\n", r.MaxDescent, r.MaxDescentAt)
+	}
+	fmt.Fprintf(w, "  min:             %g, at window %d\n", r.Min, r.MinAt)
+	fmt.Fprintf(w, "  max:             %g, at window %d\n", r.Max, r.MaxAt)
+	fmt.Fprintf(w, "  mean:            %g\n", r.Mean())
+}
+
+// reports sends each reading to several reports, so that every window size is
+// covered in a single pass over the input.
+type reports []*Report
+
+func (rs reports) Process(s string) {
+	for _, r := range rs {
+		r.Process(s)
+	}
+}
+
+// Result is not meaningful for more than one report; print them instead.
+func (rs reports) Result() int {
+	return 0
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Resampler reads timestamp,depth lines and passes the mean depth of each
+// fixed-length time bucket on to another Processor, so that windows and
+// reports compare stretches of time rather than numbers of samples.
+//
+// Timestamps are either RFC 3339 times or Unix seconds. A reading that belongs
+// to a bucket that has already been passed on is counted as late and dropped,
+// and buckets with no readings are skipped rather than invented. A run of
+// skipped buckets is passed on as a gap to a next Processor that is a Gapper,
+// which starts the windows afresh, so a window never spans more time than its
+// buckets cover. Only the bucket being filled is held in memory.
+type Resampler struct {
+	next   Processor
+	bucket time.Duration
+
+	line    int
+	started bool
+	// cur is the start of the bucket being filled
+	cur   time.Time
+	sum   float64
+	count int
+
+	Readings int
+	Buckets  int
+	Skipped  int
+	Late     int
+	// Header is true if the first line was a column header rather than data.
+	Header bool
+}
+
+func NewResampler(next Processor, bucket time.Duration) *Resampler {
+	return &Resampler{
+		next:   next,
+		bucket: bucket,
+	}
+}
+
+func parseTimestamp(s string) (time.Time, error) {
+	if secs, err := strconv.ParseFloat(s, 64); err == nil {
+		whole, frac := math.Modf(secs)
+		return time.Unix(int64(whole), int64(frac*1e9)).UTC(), nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+func (r *Resampler) Process(s string) {
+	r.line++
+	if strings.TrimSpace(s) == "" {
+		return
+	}
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		panic(fmt.Errorf("line %d: expected timestamp,depth, got %q", r.line, s))
+	}
+	ts, err := parseTimestamp(strings.TrimSpace(parts[0]))
+	if err != nil {
+		if r.line == 1 {
+			r.Header = true
+			return
+		}
+		panic(fmt.Errorf("line %d: %w", r.line, err))
+	}
+	depth, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		panic(fmt.Errorf("line %d: %w", r.line, err))
+	}
+
+	start := ts.Truncate(r.bucket)
+	switch {
+	case !r.started:
+		r.started = true
+		r.cur = start
+	case start.Before(r.cur):
+		r.Late++
+		return
+	case start.After(r.cur):
+		r.flush()
+		if skipped := int(start.Sub(r.cur)/r.bucket) - 1; skipped > 0 {
+			r.Skipped += skipped
+			if g, ok := r.next.(Gapper); ok {
+				g.Gap()
+			}
+		}
+		r.cur = start
+	}
+	r.sum += depth
+	r.count++
+	r.Readings++
+}
+
+func (r *Resampler) flush() {
+	if r.count == 0 {
+		return
+	}
+	r.next.Process(strconv.FormatFloat(r.sum/float64(r.count), 'f', -1, 64))
+	r.Buckets++
+	r.sum = 0
+	r.count = 0
+}
+
+// Finish must be called at the end of the input to pass on the last bucket.
+func (r *Resampler) Finish() {
+	r.flush()
+}
+
+func (r *Resampler) Result() int {
+	return r.next.Result()
+}
+
+func (r *Resampler) Print(w io.Writer) {
+	fmt.Fprintf(w, "%d readings in %d buckets of %v", r.Readings, r.Buckets, r.bucket)
+	if r.Skipped > 0 {
+		fmt.Fprintf(w, ", %d empty buckets skipped", r.Skipped)
+	}
+	if r.Late > 0 {
+		fmt.Fprintf(w, ", %d out of order readings dropped", r.Late)
+	}
+	fmt.Fprintln(w)
+}
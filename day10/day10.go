@@ -1,13 +1,16 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
 	"os"
 	"sort"
+
+	"advent-2021/input"
 )
 
 func main() {
+	flag.Parse()
 	process(&Part1{})
 	process(&Part2{})
 }
@@ -211,12 +214,13 @@ func process(p Processor) {
 		panic(err)
 	}
 	defer f.Close()
-	scanner := bufio.NewScanner(f)
-
-	scanner.Split(bufio.ScanLines)
+	scanner := input.Lines(f, "./day10/input.txt")
 
 	for scanner.Scan() {
 		p.Process(scanner.Text())
 	}
+	if err := scanner.Err(); err != nil {
+		panic(err)
+	}
 	fmt.Println(p.Result())
 }
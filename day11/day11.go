@@ -1,16 +1,30 @@
 package main
 
 import (
-	"bytes"
+	"flag"
 	"fmt"
 	"os"
+
+	"advent-2021/eventlog"
+	"advent-2021/input"
+	"advent-2021/repl"
 )
 
 func main() {
+	flag.Parse()
+	defer events.MustClose()
+	if flag.Arg(0) == "repl" {
+		if err := repl.Run("day11", &octopusSim{board: getInitial()}, os.Stdin, os.Stdout); err != nil {
+			panic(err)
+		}
+		return
+	}
 	//part1()
 	part2()
 }
 
+var events = eventlog.FromFlag()
+
 /*
 There are 100 octopuses arranged neatly in a 10 by 10 grid. Each octopus slowly gains energy over time and flashes brightly for a moment when its energy is full. Although your lights are off, maybe you could navigate through the cave without disturbing the octopuses if you could predict when the flashes of light will happen.
 
@@ -315,8 +329,10 @@ func part1() {
 	start := getInitial()
 	printBoard(start)
 	total := 0
+	events.Emit("part", nil, "part 1")
 	for i := 0; i < 100; i++ {
 		fmt.Println(i + 1)
+		events.Emit("step", eventlog.Fields{"step": i + 1}, "step %d", i+1)
 		increment(start)
 		fmt.Println("after initial increment")
 		printBoard(start)
@@ -380,9 +396,11 @@ func part2() {
 	printBoard(start)
 	count := 0
 	boardSize := len(start) * len(start[0])
+	events.Emit("part", nil, "part 2")
 loop:
 	for {
 		fmt.Println(count + 1)
+		events.Emit("step", eventlog.Fields{"step": count + 1}, "step %d", count+1)
 		increment(start)
 		var totalPopped int
 		for {
@@ -390,6 +408,7 @@ loop:
 			fmt.Println("number popped", popped)
 			totalPopped += popped
 			if totalPopped == boardSize {
+				events.Emit("sync", eventlog.Fields{"step": count + 1}, "all octopuses flashed on step %d", count+1)
 				break loop
 			}
 			if popped == 0 {
@@ -452,6 +471,9 @@ func flash(board [][]byte) int {
 				}
 				board[i][j] = 0
 				count++
+				if events.Enabled() {
+					events.Emit("flash", eventlog.Fields{"row": i, "col": j}, "octopus (%d,%d) flashed", i, j)
+				}
 			}
 		}
 	}
@@ -475,8 +497,14 @@ func printBoard(board [][]byte) {
 	}
 }
 func getInitial() [][]byte {
-	contents, _ := os.ReadFile("./day11/input.txt")
-	//	contents = []byte(`5483143223
+	f, err := os.Open("./day11/input.txt")
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	scanner := input.Lines(f, "./day11/input.txt")
+
+	//	scanner = bufio.NewScanner(strings.NewReader(`5483143223
 	//2745854711
 	//5264556173
 	//6141336146
@@ -485,16 +513,18 @@ func getInitial() [][]byte {
 	//2176841721
 	//6882881134
 	//4846848554
-	//5283751526`)
-	grid := bytes.Split(contents, []byte{'\n'})
-	for i := 0; i < len(grid); i++ {
-		for j := 0; j < len(grid[i]); j++ {
-			grid[i][j] = grid[i][j] - '0'
+	//5283751526`))
+
+	var grid [][]byte
+	for scanner.Scan() {
+		row := append([]byte(nil), scanner.Bytes()...)
+		for j := 0; j < len(row); j++ {
+			row[j] = row[j] - '0'
 		}
+		grid = append(grid, row)
 	}
-	if len(grid[len(grid)-1]) == 0 {
-		grid = grid[:len(grid)-1]
+	if err := scanner.Err(); err != nil {
+		panic(err)
 	}
-	// last line is blank
 	return grid
 }
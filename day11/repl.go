@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// octopusSim runs the energy grid one step at a time for the repl.
+type octopusSim struct {
+	board [][]byte
+	// flashes is the total number of flashes so far, last is the number in the
+	// most recent step
+	flashes int
+	last    int
+}
+
+type octopusState struct {
+	board   [][]byte
+	flashes int
+	last    int
+}
+
+func copyBoard(board [][]byte) [][]byte {
+	out := make([][]byte, len(board))
+	for i := range board {
+		out[i] = append([]byte(nil), board[i]...)
+	}
+	return out
+}
+
+func (o *octopusSim) Step() error {
+	increment(o.board)
+	o.last = 0
+	for {
+		count := flash(o.board)
+		if count == 0 {
+			break
+		}
+		o.last += count
+	}
+	o.flashes += o.last
+	return nil
+}
+
+func (o *octopusSim) Show(w io.Writer) {
+	for i := 0; i < len(o.board); i++ {
+		for j := 0; j < len(o.board[i]); j++ {
+			fmt.Fprint(w, o.board[i][j])
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "%d flashed last step, %d in total", o.last, o.flashes)
+	if o.last == len(o.board)*len(o.board[0]) {
+		fmt.Fprint(w, ", all at once")
+	}
+	fmt.Fprintln(w)
+}
+
+func (o *octopusSim) Usage() string {
+	return "<row> <col> <energy>"
+}
+
+func (o *octopusSim) Set(args []string) error {
+	if len(args) != 3 {
+		return errors.New("wrong number of arguments")
+	}
+	var vals [3]int
+	for i := range vals {
+		n, err := strconv.Atoi(args[i])
+		if err != nil {
+			return err
+		}
+		vals[i] = n
+	}
+	i, j, energy := vals[0], vals[1], vals[2]
+	if i < 0 || i >= len(o.board) || j < 0 || j >= len(o.board[i]) {
+		return fmt.Errorf("no octopus at %d,%d", i, j)
+	}
+	if energy < 0 || energy > 9 {
+		return fmt.Errorf("energy %d is not between 0 and 9", energy)
+	}
+	o.board[i][j] = byte(energy)
+	return nil
+}
+
+func (o *octopusSim) Snapshot() interface{} {
+	return octopusState{
+		board:   copyBoard(o.board),
+		flashes: o.flashes,
+		last:    o.last,
+	}
+}
+
+func (o *octopusSim) Restore(state interface{}) {
+	s := state.(octopusState)
+	o.board = copyBoard(s.board)
+	o.flashes = s.flashes
+	o.last = s.last
+}
@@ -1,15 +1,18 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
 	"os"
 	"strings"
 	"time"
 	"unicode"
+
+	"advent-2021/input"
 )
 
 func main() {
+	flag.Parse()
 	process(&Part1{
 		nodes: map[string]*Node{},
 	})
@@ -231,7 +234,7 @@ func process(p Processor) {
 		panic(err)
 	}
 	defer f.Close()
-	scanner := bufio.NewScanner(f)
+	scanner := input.Lines(f, "./day12/input.txt")
 
 	//	scanner = bufio.NewScanner(strings.NewReader(`
 	//start-A
@@ -242,10 +245,11 @@ func process(p Processor) {
 	//A-end
 	//b-end`))
 
-	scanner.Split(bufio.ScanLines)
-
 	for scanner.Scan() {
 		p.Process(scanner.Text())
 	}
+	if err := scanner.Err(); err != nil {
+		panic(err)
+	}
 	fmt.Println(p.Result())
 }
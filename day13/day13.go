@@ -1,18 +1,34 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
+
+	"advent-2021/eventlog"
+	"advent-2021/input"
+	"advent-2021/repl"
 )
 
 func main() {
+	flag.Parse()
+	defer events.MustClose()
+	if flag.Arg(0) == "repl" {
+		p := &Part2{}
+		load(p)
+		if err := repl.Run("day13", newFoldSim(p), os.Stdin, os.Stdout); err != nil {
+			panic(err)
+		}
+		return
+	}
 	process(&Part1{})
 	process(&Part2{})
 }
 
+var events = eventlog.FromFlag()
+
 /*
 6,10
 0,14
@@ -148,14 +164,20 @@ func (p *Part1) Result() int {
 	firstFold := p.folds[0]
 	switch firstFold.axis {
 	case 'x':
+		merged := 0
 		for i := 0; i < len(p.grid); i++ {
 			for j := firstFold.pos + 1; j < len(p.grid[i]); j++ {
 				if p.grid[i][j] {
+					if p.grid[i][firstFold.pos-(j-firstFold.pos)] {
+						merged++
+					}
 					p.grid[i][firstFold.pos-(j-firstFold.pos)] = true
 					p.grid[i][j] = false
 				}
 			}
 		}
+		events.Emit("fold", eventlog.Fields{"axis": "x", "pos": firstFold.pos, "merged": merged},
+			"fold x=%d merged %d dots", firstFold.pos, merged)
 		return p.count(firstFold.pos, len(p.grid))
 	case 'y':
 	}
@@ -216,34 +238,51 @@ func (p *Part2) Result() int {
 		switch fold.axis {
 		case 'x':
 			lastX = fold.pos
-			for i := 0; i < len(p.grid); i++ {
-				for j := fold.pos + 1; j < len(p.grid[i]); j++ {
-					if p.grid[i][j] {
-						p.grid[i][fold.pos-(j-fold.pos)] = true
-						p.grid[i][j] = false
+		case 'y':
+			lastY = fold.pos
+		}
+		p.fold(fold)
+	}
+	p.printGrid(lastX, lastY)
+	return 0
+}
+
+func (p *Part2) fold(fold Fold) {
+	merged := 0
+	switch fold.axis {
+	case 'x':
+		for i := 0; i < len(p.grid); i++ {
+			for j := fold.pos + 1; j < len(p.grid[i]); j++ {
+				if p.grid[i][j] {
+					if p.grid[i][fold.pos-(j-fold.pos)] {
+						merged++
 					}
+					p.grid[i][fold.pos-(j-fold.pos)] = true
+					p.grid[i][j] = false
 				}
 			}
-		case 'y':
-			lastY = fold.pos
-			for i := fold.pos + 1; i < len(p.grid); i++ {
-				for j := 0; j < len(p.grid[i]); j++ {
-					if p.grid[i][j] {
-						matchRow := fold.pos - (i - fold.pos)
-						if len(p.grid[matchRow]) < j {
-							for k := len(p.grid[matchRow]); k <= j; k++ {
-								p.grid[matchRow] = append(p.grid[matchRow], false)
-							}
+		}
+	case 'y':
+		for i := fold.pos + 1; i < len(p.grid); i++ {
+			for j := 0; j < len(p.grid[i]); j++ {
+				if p.grid[i][j] {
+					matchRow := fold.pos - (i - fold.pos)
+					if len(p.grid[matchRow]) < j {
+						for k := len(p.grid[matchRow]); k <= j; k++ {
+							p.grid[matchRow] = append(p.grid[matchRow], false)
 						}
-						p.grid[matchRow][j] = true
-						p.grid[i][j] = false
 					}
+					if p.grid[matchRow][j] {
+						merged++
+					}
+					p.grid[matchRow][j] = true
+					p.grid[i][j] = false
 				}
 			}
 		}
 	}
-	p.printGrid(lastX, lastY)
-	return 0
+	events.Emit("fold", eventlog.Fields{"axis": string(fold.axis), "pos": fold.pos, "merged": merged},
+		"fold %c=%d merged %d dots", fold.axis, fold.pos, merged)
 }
 
 func (p *Part2) printGrid(maxX int, maxY int) {
@@ -266,17 +305,22 @@ type Processor interface {
 }
 
 func process(p Processor) {
+	load(p)
+	fmt.Println(p.Result())
+}
+
+func load(p Processor) {
 	f, err := os.Open("./day13/input.txt")
 	if err != nil {
 		panic(err)
 	}
 	defer f.Close()
-	scanner := bufio.NewScanner(f)
-
-	scanner.Split(bufio.ScanLines)
+	scanner := input.Lines(f, "./day13/input.txt")
 
 	for scanner.Scan() {
 		p.Process(scanner.Text())
 	}
-	fmt.Println(p.Result())
+	if err := scanner.Err(); err != nil {
+		panic(err)
+	}
 }
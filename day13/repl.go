@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"advent-2021/repl"
+)
+
+// foldSim makes the folds one at a time for the repl.
+type foldSim struct {
+	p    *Part2
+	next int
+	// width and height are the size of the paper after the folds so far
+	width  int
+	height int
+}
+
+type foldState struct {
+	grid   [][]bool
+	next   int
+	width  int
+	height int
+}
+
+func newFoldSim(p *Part2) *foldSim {
+	f := &foldSim{p: p}
+	f.height = len(p.grid)
+	for _, row := range p.grid {
+		if len(row) > f.width {
+			f.width = len(row)
+		}
+	}
+	return f
+}
+
+func copyGrid(grid [][]bool) [][]bool {
+	out := make([][]bool, len(grid))
+	for i := range grid {
+		out[i] = append([]bool(nil), grid[i]...)
+	}
+	return out
+}
+
+func (f *foldSim) dots() int {
+	total := 0
+	for i := 0; i < f.height && i < len(f.p.grid); i++ {
+		for j := 0; j < f.width && j < len(f.p.grid[i]); j++ {
+			if f.p.grid[i][j] {
+				total++
+			}
+		}
+	}
+	return total
+}
+
+func (f *foldSim) Step() error {
+	if f.next >= len(f.p.folds) {
+		return repl.ErrDone
+	}
+	fold := f.p.folds[f.next]
+	f.p.fold(fold)
+	f.next++
+	switch fold.axis {
+	case 'x':
+		f.width = fold.pos
+	case 'y':
+		f.height = fold.pos
+	}
+	return nil
+}
+
+func (f *foldSim) Show(w io.Writer) {
+	for i := 0; i < f.height; i++ {
+		for j := 0; j < f.width; j++ {
+			if i < len(f.p.grid) && j < len(f.p.grid[i]) && f.p.grid[i][j] {
+				fmt.Fprint(w, "#")
+			} else {
+				fmt.Fprint(w, ".")
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "%d of %d folds made, %d dots visible", f.next, len(f.p.folds), f.dots())
+	if f.next < len(f.p.folds) {
+		fold := f.p.folds[f.next]
+		fmt.Fprintf(w, ", next is along %c=%d", fold.axis, fold.pos)
+	}
+	fmt.Fprintln(w)
+}
+
+func (f *foldSim) Usage() string {
+	return "<x> <y> on|off"
+}
+
+func (f *foldSim) Set(args []string) error {
+	if len(args) != 3 {
+		return errors.New("wrong number of arguments")
+	}
+	x, err := strconv.Atoi(args[0])
+	if err != nil {
+		return err
+	}
+	y, err := strconv.Atoi(args[1])
+	if err != nil {
+		return err
+	}
+	if x < 0 || x >= f.width || y < 0 || y >= f.height {
+		return fmt.Errorf("%d,%d is off the paper", x, y)
+	}
+	var on bool
+	switch args[2] {
+	case "on":
+		on = true
+	case "off":
+	default:
+		return fmt.Errorf("expected on or off, got %q", args[2])
+	}
+	for i := len(f.p.grid); i <= y; i++ {
+		f.p.grid = append(f.p.grid, []bool{})
+	}
+	for i := len(f.p.grid[y]); i <= x; i++ {
+		f.p.grid[y] = append(f.p.grid[y], false)
+	}
+	f.p.grid[y][x] = on
+	return nil
+}
+
+func (f *foldSim) Snapshot() interface{} {
+	return foldState{
+		grid:   copyGrid(f.p.grid),
+		next:   f.next,
+		width:  f.width,
+		height: f.height,
+	}
+}
+
+func (f *foldSim) Restore(state interface{}) {
+	s := state.(foldState)
+	f.p.grid = copyGrid(s.grid)
+	f.next = s.next
+	f.width = s.width
+	f.height = s.height
+}
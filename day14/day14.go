@@ -1,15 +1,31 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"strings"
 	"time"
+
+	"advent-2021/difftest"
+	"advent-2021/input"
+	"advent-2021/repl"
 )
 
 func main() {
+	flag.Parse()
+	if flag.Arg(0) == "diff" {
+		difftest.Main(flag.Args()[1:], polymerProperty)
+		return
+	}
+	if flag.Arg(0) == "repl" {
+		if err := repl.Run("day14", &polymerSim{data: buildData()}, os.Stdin, os.Stdout); err != nil {
+			panic(err)
+		}
+		return
+	}
 	part1()
 	part2()
 }
@@ -64,29 +80,27 @@ Apply 10 steps of pair insertion to the polymer template and find the most and l
 func part1() {
 	data := buildData()
 	start := time.Now()
-	for i := 0; i < 10; i++ {
-		newRow := make([]byte, len(data.curChain)*2-1)
-		for i := 0; i < len(data.curChain)-1; i++ {
-			newRow[i*2] = data.curChain[i]
-			s := data.curChain[i : i+2]
-			newRow[i*2+1] = byte(data.rules[s])
-		}
-		newRow[len(newRow)-1] = data.curChain[len(data.curChain)-1]
-		data.curChain = string(newRow)
-	}
-	counts := calcCounts(data.curChain)
+	counts := calcCounts(polymerize(data, 10))
 	fmt.Println(time.Since(start))
-	minCount := math.MaxInt
-	maxCount := 0
-	for _, v := range counts {
-		if v > maxCount {
-			maxCount = v
-		}
-		if v < minCount {
-			minCount = v
+	maxCount, minCount := spread(counts)
+	fmt.Println(maxCount, minCount, maxCount-minCount)
+}
+
+// polymerize builds the whole chain after the given number of steps. It is the
+// reference implementation that pairCounts is checked against.
+func polymerize(data Data, steps int) string {
+	chain := data.curChain
+	for i := 0; i < steps; i++ {
+		newRow := make([]byte, len(chain)*2-1)
+		for i := 0; i < len(chain)-1; i++ {
+			newRow[i*2] = chain[i]
+			s := chain[i : i+2]
+			newRow[i*2+1] = byte(data.rules[s])
 		}
+		newRow[len(newRow)-1] = chain[len(chain)-1]
+		chain = string(newRow)
 	}
-	fmt.Println(maxCount, minCount, maxCount-minCount)
+	return chain
 }
 
 func calcCounts(s string) map[rune]int {
@@ -109,30 +123,48 @@ What do you get if you take the quantity of the most common element and subtract
 element?
 */
 
-const max = 40
-
 func part2() {
 	data := buildData()
+	counts := pairCounts(data, 40, os.Stdout)
+	maxCount, minCount := spread(counts)
+	fmt.Println(maxCount, minCount, maxCount-minCount)
+}
+
+// pairCounts counts the elements in the chain after the given number of steps
+// without building it, by memoizing what each pair expands to at each depth.
+// If timings isn't nil, the time taken to expand each pair of the template is
+// written to it.
+func pairCounts(data Data, steps int, timings io.Writer) map[rune]int {
+	counts := map[rune]int{}
+	// add in the counts for the initial string
+	for _, v := range data.curChain {
+		counts[v]++
+	}
+	if steps == 0 {
+		return counts
+	}
 	// each pair produces a new letter to count
 	allCounts := map[string][]map[rune]int{}
 	for j := 0; j < len(data.curChain)-1; j++ {
 		start := time.Now()
 		key := data.curChain[j : j+2]
-		inner(0, key, data.rules, allCounts)
-		fmt.Println(j/2, key, time.Since(start))
+		inner(0, steps, key, data.rules, allCounts)
+		if timings != nil {
+			fmt.Fprintln(timings, j/2, key, time.Since(start))
+		}
 	}
 	// sum up all the counts for all the pairs in the top level
-	counts := map[rune]int{}
 	for j := 0; j < len(data.curChain)-1; j++ {
 		key := data.curChain[j : j+2]
 		for k2, v2 := range allCounts[key][0] {
 			counts[k2] += v2
 		}
 	}
-	// add in the counts for the initial string
-	for _, v := range data.curChain {
-		counts[v]++
-	}
+	return counts
+}
+
+// spread returns the largest and smallest element counts.
+func spread(counts map[rune]int) (int, int) {
 	minCount := math.MaxInt
 	maxCount := 0
 	for _, v := range counts {
@@ -143,15 +175,15 @@ func part2() {
 			minCount = v
 		}
 	}
-	fmt.Println(maxCount, minCount, maxCount-minCount)
+	return maxCount, minCount
 }
 
-func inner(depth int, pair string, rules map[string]rune, counts map[string][]map[rune]int) {
+func inner(depth int, steps int, pair string, rules map[string]rune, counts map[string][]map[rune]int) {
 	// do we already know the answer for this pair at this depth?
 	keyCounts, ok := counts[pair]
 	if !ok {
 		// no row for this pair yet -- make it!
-		keyCounts = make([]map[rune]int, max)
+		keyCounts = make([]map[rune]int, steps)
 		counts[pair] = keyCounts
 	}
 	// we have calculated this already
@@ -162,7 +194,7 @@ func inner(depth int, pair string, rules map[string]rune, counts map[string][]ma
 	curMap := map[rune]int{}
 	val := rules[pair]
 	curMap[val]++
-	if depth == max-1 {
+	if depth == steps-1 {
 		keyCounts[depth] = curMap
 		return
 	}
@@ -171,7 +203,7 @@ func inner(depth int, pair string, rules map[string]rune, counts map[string][]ma
 	next1 := string([]byte{pair[0], byte(val)})
 	nextCounts, ok := counts[next1]
 	if len(nextCounts) == 0 || nextCounts[depth+1] == nil {
-		inner(depth+1, next1, rules, counts)
+		inner(depth+1, steps, next1, rules, counts)
 		nextCounts = counts[next1] // reload
 	}
 	for k, v := range nextCounts[depth+1] {
@@ -180,7 +212,7 @@ func inner(depth int, pair string, rules map[string]rune, counts map[string][]ma
 	next2 := string([]byte{byte(val), pair[1]})
 	nextCounts2, ok := counts[next2]
 	if len(nextCounts2) == 0 || nextCounts2[depth+1] == nil {
-		inner(depth+1, next2, rules, counts)
+		inner(depth+1, steps, next2, rules, counts)
 		nextCounts2 = counts[next2] // reload
 	}
 	for k, v := range nextCounts2[depth+1] {
@@ -200,7 +232,7 @@ func buildData() Data {
 		panic(err)
 	}
 	defer f.Close()
-	scanner := bufio.NewScanner(f)
+	scanner := input.Lines(f, "./day14/input.txt")
 
 	//	scanner = bufio.NewScanner(strings.NewReader(`NNCB
 	//
@@ -220,7 +252,6 @@ func buildData() Data {
 	//BC -> B
 	//CC -> N
 	//CN -> C`))
-	scanner.Split(bufio.ScanLines)
 
 	d := Data{
 		rules: map[string]rune{},
@@ -233,6 +264,9 @@ func buildData() Data {
 		parts := strings.Split(curRow, " -> ")
 		d.rules[parts[0]] = rune(parts[1][0])
 	}
+	if err := scanner.Err(); err != nil {
+		panic(err)
+	}
 
 	return d
 }
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"math/rand"
+	"strings"
+
+	"advent-2021/difftest"
+)
+
+type polymerInput struct {
+	Template string
+	Rules    []string
+	Steps    int
+}
+
+func (in polymerInput) data() Data {
+	d := Data{
+		curChain: in.Template,
+		rules:    map[string]rune{},
+	}
+	for _, v := range in.Rules {
+		parts := strings.Split(v, " -> ")
+		d.rules[parts[0]] = rune(parts[1][0])
+	}
+	return d
+}
+
+// polymerProperty checks pairCounts against polymerize. Every pair of elements
+// gets a rule, as in the puzzle input, and steps are kept small enough for the
+// chain to be built.
+var polymerProperty = difftest.Property{
+	Name: "day14 polymerize vs pairCounts",
+	Generate: func(r *rand.Rand) interface{} {
+		elements := "BCHNOP"[:r.Intn(4)+2]
+		var in polymerInput
+		for i := r.Intn(6) + 1; i > 0; i-- {
+			in.Template += string(elements[r.Intn(len(elements))])
+		}
+		for _, a := range elements {
+			for _, b := range elements {
+				in.Rules = append(in.Rules, string(a)+string(b)+" -> "+string(elements[r.Intn(len(elements))]))
+			}
+		}
+		in.Steps = r.Intn(9)
+		return in
+	},
+	Shrink: func(v interface{}) []interface{} {
+		in := v.(polymerInput)
+		var out []interface{}
+		if in.Steps > 0 {
+			out = append(out, polymerInput{Template: in.Template, Rules: in.Rules, Steps: in.Steps - 1})
+		}
+		for i := 0; len(in.Template) > 1 && i < len(in.Template); i++ {
+			out = append(out, polymerInput{Template: in.Template[:i] + in.Template[i+1:], Rules: in.Rules, Steps: in.Steps})
+		}
+		return out
+	},
+	Reference: func(v interface{}) interface{} {
+		in := v.(polymerInput)
+		return calcCounts(polymerize(in.data(), in.Steps))
+	},
+	Optimized: func(v interface{}) interface{} {
+		in := v.(polymerInput)
+		return pairCounts(in.data(), in.Steps, nil)
+	},
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// polymerSim applies one step of pair insertion at a time for the repl.
+type polymerSim struct {
+	data Data
+}
+
+func (p *polymerSim) Step() error {
+	p.data.curChain = polymerize(p.data, 1)
+	return nil
+}
+
+func (p *polymerSim) Show(w io.Writer) {
+	chain := p.data.curChain
+	if len(chain) > 200 {
+		chain = chain[:200] + "..."
+	}
+	fmt.Fprintf(w, "%s (length %d)\n", chain, len(p.data.curChain))
+	counts := calcCounts(p.data.curChain)
+	var elements []rune
+	for k := range counts {
+		elements = append(elements, k)
+	}
+	sort.Slice(elements, func(i, j int) bool {
+		return elements[i] < elements[j]
+	})
+	for _, k := range elements {
+		fmt.Fprintf(w, "  %c: %d\n", k, counts[k])
+	}
+	maxCount, minCount := spread(counts)
+	fmt.Fprintln(w, "most - least:", maxCount-minCount)
+}
+
+func (p *polymerSim) Usage() string {
+	return "<chain>"
+}
+
+func (p *polymerSim) Set(args []string) error {
+	if len(args) != 1 || len(args[0]) == 0 {
+		return errors.New("expected a single chain")
+	}
+	for i := 0; i < len(args[0])-1; i++ {
+		if _, ok := p.data.rules[args[0][i:i+2]]; !ok {
+			return fmt.Errorf("no rule for pair %s", args[0][i:i+2])
+		}
+	}
+	p.data.curChain = args[0]
+	return nil
+}
+
+func (p *polymerSim) Snapshot() interface{} {
+	return p.data.curChain
+}
+
+func (p *polymerSim) Restore(state interface{}) {
+	p.data.curChain = state.(string)
+}
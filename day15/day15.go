@@ -1,18 +1,25 @@
 package main
 
 import (
-	"bytes"
+	"flag"
 	"fmt"
 	"math"
 	"os"
 	"time"
+
+	"advent-2021/eventlog"
+	"advent-2021/input"
 )
 
 func main() {
+	flag.Parse()
+	defer events.MustClose()
 	part1()
 	part2()
 }
 
+var events = eventlog.FromFlag()
+
 /*
 1163751742
 1381373672
@@ -157,6 +164,9 @@ func dijkstra(graph [][]byte, source point) (map[point]int, map[point]point) {
 		delete(q, u)
 		delete(dist, u)
 		outDist[u] = distU
+		if events.Enabled() {
+			events.Emit("settle", eventlog.Fields{"x": u.x, "y": u.y, "dist": distU}, "dijkstra settled (%d,%d) at %d", u.x, u.y, distU)
+		}
 		n := neighbors(u, q)
 		for _, v := range n {
 			alt := distU + int(graph[v.y][v.x])
@@ -177,7 +187,12 @@ func minDistance(points map[point]int) (point, int) {
 	var lowest point
 	lowestScore := math.MaxInt
 	for p, score := range points {
-		if score < lowestScore {
+		// break ties by position so that runs settle points in the same order.
+		// Map iteration is random, so otherwise two runs over the same input
+		// could log their settles in different orders and not diff cleanly.
+		// Any of the tied points is a correct next point, so the distances
+		// don't change.
+		if score < lowestScore || (score == lowestScore && (p.y < lowest.y || (p.y == lowest.y && p.x < lowest.x))) {
 			lowestScore = score
 			lowest = p
 		}
@@ -203,8 +218,14 @@ func neighbors(u point, q map[point]bool) []point {
 }
 
 func loadData() [][]byte {
-	contents, _ := os.ReadFile("./day15/input.txt")
-	//	contents = []byte(`1163751742
+	f, err := os.Open("./day15/input.txt")
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	scanner := input.Lines(f, "./day15/input.txt")
+
+	//	scanner = bufio.NewScanner(strings.NewReader(`1163751742
 	//1381373672
 	//2136511328
 	//3694931569
@@ -213,16 +234,18 @@ func loadData() [][]byte {
 	//1359912421
 	//3125421639
 	//1293138521
-	//2311944581`)
-	grid := bytes.Split(contents, []byte{'\n'})
-	for i := 0; i < len(grid); i++ {
-		for j := 0; j < len(grid[i]); j++ {
-			grid[i][j] = grid[i][j] - '0'
+	//2311944581`))
+
+	var grid [][]byte
+	for scanner.Scan() {
+		row := append([]byte(nil), scanner.Bytes()...)
+		for j := 0; j < len(row); j++ {
+			row[j] = row[j] - '0'
 		}
+		grid = append(grid, row)
 	}
-	if len(grid[len(grid)-1]) == 0 {
-		grid = grid[:len(grid)-1]
+	if err := scanner.Err(); err != nil {
+		panic(err)
 	}
-	// last line is blank
 	return grid
 }
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
 	"math"
 	"os"
 	"strconv"
+	"strings"
+	"unicode"
 )
 
 func main() {
@@ -199,7 +202,12 @@ var hexLookup = map[byte]string{
 	'F': "1111",
 }
 
+// hexToBits returns the four bits of the hex digit b, which can be upper or
+// lower case, or "" if b isn't a hex digit.
 func hexToBits(b byte) string {
+	if 'a' <= b && b <= 'f' {
+		b -= 'a' - 'A'
+	}
 	return hexLookup[b]
 }
 
@@ -318,10 +326,50 @@ type Processor interface {
 }
 
 func process(p Processor) {
-	data, err := os.ReadFile("./day16/input.txt")
+	f, err := os.Open("./day16/input.txt")
 	if err != nil {
 		panic(err)
 	}
-	p.Process(string(data))
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Split(scanHex)
+
+	var data strings.Builder
+	for scanner.Scan() {
+		data.Write(scanner.Bytes())
+	}
+	if err := scanner.Err(); err != nil {
+		panic(fmt.Errorf("./day16/input.txt: %w", err))
+	}
+	p.Process(data.String())
 	fmt.Println(p.Result())
 }
+
+// scanHex is a bufio.SplitFunc that returns the hex digits of a transmission in
+// whatever sized pieces are buffered, so the transmission can be any length.
+// Whitespace is skipped and any other character is an error rather than being
+// silently decoded as nothing.
+func scanHex(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	start := 0
+	for start < len(data) && unicode.IsSpace(rune(data[start])) {
+		start++
+	}
+	end := start
+	for end < len(data) {
+		b := data[end]
+		if unicode.IsSpace(rune(b)) {
+			break
+		}
+		if hexToBits(b) == "" {
+			return 0, nil, fmt.Errorf("invalid hex digit %q", b)
+		}
+		end++
+	}
+	if end > start {
+		return end, data[start:end], nil
+	}
+	if atEOF {
+		return len(data), nil, nil
+	}
+	return start, nil, nil
+}
@@ -1,16 +1,218 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
 	"os"
-	"strconv"
+	"sort"
 	"strings"
+
+	"advent-2021/difftest"
+	"advent-2021/input"
 )
 
 func main() {
-	process(&Part1{})
-	process(&Part2{})
+	flag.Parse()
+	if flag.Arg(0) == "diff" {
+		difftest.Main(flag.Args()[1:], planProperty("part1"), planProperty("part2"))
+		return
+	}
+	if flag.Arg(0) == "run" {
+		fs := flag.NewFlagSet("run", flag.ExitOnError)
+		model := fs.String("model", "part2", "motion model to use: "+strings.Join(modelNames(), ", "))
+		fs.Parse(flag.Args()[1:])
+		m, ok := models[*model]
+		if !ok {
+			fs.Usage()
+			os.Exit(2)
+		}
+		sub := NewSub(m)
+		process(sub)
+		if d, ok := m.(Describer); ok {
+			fmt.Println(d.Describe(sub.State))
+		}
+		return
+	}
+	if flag.Arg(0) == "plan" {
+		fs := flag.NewFlagSet("plan", flag.ExitOnError)
+		model := fs.String("model", "part2", "motion model to plan for: part1 or part2")
+		horiz := fs.Int("horiz", 0, "horizontal position to reach")
+		depth := fs.Int("depth", 0, "depth to reach")
+		fs.Parse(flag.Args()[1:])
+		if _, ok := models[*model]; !ok {
+			fs.Usage()
+			os.Exit(2)
+		}
+		course, err := Plan(*model, *horiz, *depth)
+		if err != nil {
+			panic(err)
+		}
+		for _, c := range course {
+			fmt.Println(c)
+		}
+		fmt.Fprintf(os.Stderr, "%d commands, checked: horizontal %d, depth %d\n", len(course), *horiz, *depth)
+		return
+	}
+	if flag.Arg(0) == "trace" {
+		fs := flag.NewFlagSet("trace", flag.ExitOnError)
+		model := fs.String("model", "part2", "motion model to use: "+strings.Join(modelNames(), ", "))
+		format := fs.String("format", "csv", "trajectory format: csv or json")
+		out := fs.String("o", "", "file to write the trajectory to (default stdout)")
+		var limits Limits
+		fs.IntVar(&limits.MaxDepth, "maxdepth", 0, "deepest safe depth, 0 for no limit")
+		fs.IntVar(&limits.MaxAim, "maxaim", 0, "largest safe aim either way, 0 for no limit")
+		fs.BoolVar(&limits.AllowAboveSurface, "abovesurface", false, "allow negative depths")
+		fs.Parse(flag.Args()[1:])
+		m, ok := models[*model]
+		if !ok {
+			fs.Usage()
+			os.Exit(2)
+		}
+		w := os.Stdout
+		if *out != "" {
+			f, err := os.Create(*out)
+			if err != nil {
+				panic(err)
+			}
+			defer f.Close()
+			w = f
+		}
+		t, err := NewTrajectory(w, *format)
+		if err != nil {
+			fs.Usage()
+			os.Exit(2)
+		}
+		safety := &Safety{Limits: limits}
+		sub := NewSub(m)
+		sub.Observe(t)
+		sub.Observe(safety)
+		load(sub)
+		if err := t.Close(); err != nil {
+			panic(err)
+		}
+		if safety.First != nil {
+			fmt.Fprintf(os.Stderr, "unsafe: %v; %d commands broke the limits\n", safety.First, safety.Count)
+			os.Exit(1)
+		}
+		return
+	}
+	process(NewSub(Part1{}))
+	process(NewSub(Part2{}))
+}
+
+// Command is a single parsed command of the planned course. Turns are in
+// degrees, with right positive.
+type Command struct {
+	Verb  string
+	Value int
+	Line  int
+}
+
+// unsupported is the error a Model returns for a command it has no meaning for.
+func unsupported(model string, c Command) error {
+	return fmt.Errorf("the %s model has no %s command", model, c.Verb)
+}
+
+// State is where the submarine is. Not every model uses every field.
+type State struct {
+	Horiz int
+	Depth int
+	Aim   int
+	// Across is how far the submarine is to the right of where it started,
+	// and Heading is the direction it faces in degrees, right of straight
+	// ahead.
+	Across  int
+	Heading int
+}
+
+// Model decides what each command does to the submarine's state. The parser
+// and the driver know nothing about what the commands mean, so a new way of
+// interpreting a course only needs a new Model and a call to RegisterModel.
+type Model interface {
+	Apply(s *State, c Command) error
+}
+
+var models = map[string]Model{}
+
+// RegisterModel makes a model available by name to the run command.
+func RegisterModel(name string, m Model) {
+	if _, ok := models[name]; ok {
+		panic("day2: model " + name + " registered twice")
+	}
+	models[name] = m
+}
+
+func modelNames() []string {
+	var names []string
+	for k := range models {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterModel("part1", Part1{})
+	RegisterModel("part2", Part2{})
+}
+
+// Sub drives a course through a Model.
+type Sub struct {
+	model     Model
+	parser    Parser
+	observers []Observer
+	steps     int
+	State
+}
+
+func NewSub(m Model) *Sub {
+	return &Sub{model: m}
+}
+
+// Observe adds an Observer to be told about every command the sub runs.
+func (s *Sub) Observe(o Observer) {
+	s.observers = append(s.observers, o)
+}
+
+func (s *Sub) Process(line string) {
+	nodes, err := s.parser.Feed(line)
+	if err != nil {
+		panic(err)
+	}
+	if err := s.run(nodes); err != nil {
+		panic(err)
+	}
+}
+
+func (s *Sub) run(nodes []Node) error {
+	for _, n := range nodes {
+		if n.Verb == "repeat" {
+			for i := 0; i < n.Value; i++ {
+				if err := s.run(n.Body); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := s.model.Apply(&s.State, n.Command); err != nil {
+			return fmt.Errorf("line %d: %w", n.Line, err)
+		}
+		s.steps++
+		for _, o := range s.observers {
+			o.Observe(s.steps, n.Command, s.State)
+		}
+	}
+	return nil
+}
+
+func (s *Sub) Finish() {
+	if err := s.parser.Finish(); err != nil {
+		panic(err)
+	}
+}
+
+func (s *Sub) Result() int {
+	return s.Horiz * s.Depth
 }
 
 /*
@@ -45,26 +247,24 @@ After following these instructions, you would have a horizontal position of 15 a
 
 Calculate the horizontal position and depth you would have after following the planned course. What do you get if you multiply your final horizontal position by your final depth?
 */
-type Part1 struct {
-	curDepth int
-	curHoriz int
-}
+type Part1 struct{}
 
-func (p *Part1) Process(s string) {
-	parts := strings.Split(s, " ")
-	val, _ := strconv.Atoi(parts[1])
-	switch parts[0] {
+func (Part1) Apply(s *State, c Command) error {
+	switch c.Verb {
 	case "forward":
-		p.curHoriz += val
+		s.Horiz += c.Value
+	case "back":
+		s.Horiz -= c.Value
 	case "down":
-		p.curDepth += val
+		s.Depth += c.Value
 	case "up":
-		p.curDepth -= val
+		s.Depth -= c.Value
+	case "surface":
+		s.Depth = 0
+	default:
+		return unsupported("part1", c)
 	}
-}
-
-func (p *Part1) Result() int {
-	return p.curHoriz * p.curDepth
+	return nil
 }
 
 /*
@@ -89,47 +289,56 @@ down 8 adds 8 to your aim, resulting in a value of 10.
 forward 2 adds 2 to your horizontal position, a total of 15. Because your aim is 10, your depth increases by 2*10=20 to a total of 60.
 After following these new instructions, you would have a horizontal position of 15 and a depth of 60. (Multiplying these produces 900.)
 */
-type Part2 struct {
-	curDepth int
-	curHoriz int
-	curAim   int
-}
+type Part2 struct{}
 
-func (p *Part2) Process(s string) {
-	parts := strings.Split(s, " ")
-	val, _ := strconv.Atoi(parts[1])
-	switch parts[0] {
+func (Part2) Apply(s *State, c Command) error {
+	switch c.Verb {
 	case "forward":
-		p.curHoriz += val
-		p.curDepth += p.curAim * val
+		s.Horiz += c.Value
+		s.Depth += s.Aim * c.Value
+	case "back":
+		// retrace the way forward would have come
+		s.Horiz -= c.Value
+		s.Depth -= s.Aim * c.Value
 	case "down":
-		p.curAim += val
+		s.Aim += c.Value
 	case "up":
-		p.curAim -= val
+		s.Aim -= c.Value
+	case "surface":
+		s.Depth = 0
+		s.Aim = 0
+	default:
+		return unsupported("part2", c)
 	}
-}
-
-func (p *Part2) Result() int {
-	return p.curHoriz * p.curDepth
+	return nil
 }
 
 type Processor interface {
 	Process(s string)
+	// Finish is called once the whole course has been read.
+	Finish()
 	Result() int
 }
 
 func process(p Processor) {
+	load(p)
+	fmt.Println(p.Result())
+}
+
+// load runs the whole input through p.
+func load(p Processor) {
 	f, err := os.Open("./day2/input.txt")
 	if err != nil {
 		panic(err)
 	}
 	defer f.Close()
-	scanner := bufio.NewScanner(f)
-
-	scanner.Split(bufio.ScanLines)
+	scanner := input.Lines(f, "./day2/input.txt")
 
 	for scanner.Scan() {
 		p.Process(scanner.Text())
 	}
-	fmt.Println(p.Result())
+	if err := scanner.Err(); err != nil {
+		panic(err)
+	}
+	p.Finish()
 }
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+
+	"advent-2021/difftest"
+)
+
+type planInput struct {
+	Horiz, Depth int
+}
+
+// planBound is the largest target coordinate generated. Searching every course
+// of up to three commands is only cheap for small targets.
+const planBound = 6
+
+// planProperty checks that Plan finds a course as short as the shortest one a
+// search over every course of up to three commands finds. Targets at
+// horizontal 0 are generated often, as they need a move away and back.
+func planProperty(model string) difftest.Property {
+	return difftest.Property{
+		Name: "day2 shortest course vs Plan " + model,
+		Generate: func(r *rand.Rand) interface{} {
+			in := planInput{
+				Horiz: r.Intn(2*planBound+1) - planBound,
+				Depth: r.Intn(2*planBound+1) - planBound,
+			}
+			if r.Intn(4) == 0 {
+				in.Horiz = 0
+			}
+			return in
+		},
+		Shrink: func(v interface{}) []interface{} {
+			in := v.(planInput)
+			var out []interface{}
+			for _, h := range towardZero(in.Horiz) {
+				out = append(out, planInput{h, in.Depth})
+			}
+			for _, d := range towardZero(in.Depth) {
+				out = append(out, planInput{in.Horiz, d})
+			}
+			return out
+		},
+		Reference: func(v interface{}) interface{} {
+			in := v.(planInput)
+			return shortestCourse(models[model], in.Horiz, in.Depth)
+		},
+		Optimized: func(v interface{}) interface{} {
+			in := v.(planInput)
+			course, err := Plan(model, in.Horiz, in.Depth)
+			if err != nil {
+				return err.Error()
+			}
+			return len(course)
+		},
+	}
+}
+
+func towardZero(v int) []int {
+	switch {
+	case v > 0:
+		return []int{0, v - 1}
+	case v < 0:
+		return []int{0, v + 1}
+	}
+	return nil
+}
+
+// shortestCourse returns the length of the shortest course of up to three
+// forward, back, down and up commands that takes m from the surface to horiz,
+// depth, trying every one. It returns -1 if there is none that short.
+func shortestCourse(m Model, horiz, depth int) int {
+	var commands []Command
+	for _, verb := range []string{"forward", "back", "down", "up"} {
+		for v := 1; v <= 2*planBound; v++ {
+			commands = append(commands, Command{Verb: verb, Value: v})
+		}
+	}
+	var search func(s State, n int) bool
+	search = func(s State, n int) bool {
+		if n == 0 {
+			return s.Horiz == horiz && s.Depth == depth
+		}
+		for _, c := range commands {
+			next := s
+			if err := m.Apply(&next, c); err != nil {
+				panic(fmt.Errorf("%v: %w", c, err))
+			}
+			if search(next, n-1) {
+				return true
+			}
+		}
+		return false
+	}
+	for n := 0; n <= 3; n++ {
+		if search(State{}, n) {
+			return n
+		}
+	}
+	return -1
+}
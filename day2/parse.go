@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+A course is a list of commands, separated by newlines or spaces:
+
+	forward X      move forwards X units
+	back X         move backwards X units
+	down X         go down (or aim down) X units
+	up X           go up (or aim up) X units
+	turn left      turn 90 degrees to the left
+	turn right     turn 90 degrees to the right
+	turn D         turn D degrees, to the right if positive
+	left, right    the same as turn left and turn right
+	surface        come straight up to the surface
+	repeat N { ... }
+	               run the commands in the braces N times; blocks can nest and
+	               can span several lines
+
+X must be a whole number, not negative, and N at least 1. What each command does to the
+submarine is up to the Model; models reject commands they have no meaning for.
+*/
+
+// Node is a parsed command. A repeat command has Verb "repeat", the count in
+// Value and the commands to repeat in Body.
+type Node struct {
+	Command
+	Body []Node
+}
+
+type token struct {
+	text string
+	line int
+}
+
+// Parser turns the lines of a course into commands. Lines are fed in one at a
+// time, and only the tokens of an unfinished repeat block are held on to.
+type Parser struct {
+	line   int
+	depth  int
+	tokens []token
+}
+
+// Feed parses the next line of the course and returns the commands that it
+// completes. Inside a repeat block nothing is returned until the block closes.
+func (p *Parser) Feed(s string) ([]Node, error) {
+	p.line++
+	for _, field := range strings.Fields(s) {
+		// braces don't need spaces around them
+		for len(field) > 0 {
+			i := strings.IndexAny(field, "{}")
+			if i == -1 {
+				p.tokens = append(p.tokens, token{field, p.line})
+				break
+			}
+			if i > 0 {
+				p.tokens = append(p.tokens, token{field[:i], p.line})
+			}
+			p.tokens = append(p.tokens, token{field[i : i+1], p.line})
+			if field[i] == '{' {
+				p.depth++
+			} else {
+				p.depth--
+				if p.depth < 0 {
+					return nil, fmt.Errorf("line %d: } without a matching repeat", p.line)
+				}
+			}
+			field = field[i+1:]
+		}
+	}
+	if p.depth > 0 {
+		return nil, nil
+	}
+	toks := p.tokens
+	p.tokens = p.tokens[:0]
+	nodes, rest, err := parseNodes(toks)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("line %d: unexpected %q", rest[0].line, rest[0].text)
+	}
+	return nodes, nil
+}
+
+// Finish reports an error if the course ended inside a repeat block.
+func (p *Parser) Finish() error {
+	if p.depth > 0 {
+		return fmt.Errorf("line %d: repeat block is not closed", p.tokens[0].line)
+	}
+	return nil
+}
+
+// parseNodes parses commands up to the end of toks or a closing brace, and
+// returns the tokens it did not use.
+func parseNodes(toks []token) ([]Node, []token, error) {
+	var out []Node
+	for len(toks) > 0 && toks[0].text != "}" {
+		verb := toks[0]
+		toks = toks[1:]
+		n := Node{Command: Command{Verb: verb.text, Line: verb.line}}
+		switch verb.text {
+		case "forward", "back", "down", "up":
+			val, rest, err := number(verb, toks)
+			if err != nil {
+				return nil, nil, err
+			}
+			if val < 0 {
+				return nil, nil, fmt.Errorf("line %d: %s %d is negative", verb.line, verb.text, val)
+			}
+			n.Value, toks = val, rest
+		case "turn":
+			if len(toks) == 0 || toks[0].line != verb.line {
+				return nil, nil, fmt.Errorf("line %d: turn needs left, right or a number of degrees", verb.line)
+			}
+			switch toks[0].text {
+			case "left":
+				n.Value = -90
+			case "right":
+				n.Value = 90
+			default:
+				val, err := strconv.Atoi(toks[0].text)
+				if err != nil {
+					return nil, nil, fmt.Errorf("line %d: can't turn %q, expected left, right or a number of degrees", verb.line, toks[0].text)
+				}
+				n.Value = val
+			}
+			toks = toks[1:]
+		case "left":
+			n.Verb, n.Value = "turn", -90
+		case "right":
+			n.Verb, n.Value = "turn", 90
+		case "surface":
+		case "repeat":
+			val, rest, err := number(verb, toks)
+			if err != nil {
+				return nil, nil, err
+			}
+			if val < 1 {
+				return nil, nil, fmt.Errorf("line %d: repeat count must be at least 1, got %d", verb.line, val)
+			}
+			if len(rest) == 0 || rest[0].text != "{" {
+				return nil, nil, fmt.Errorf("line %d: repeat %d needs a { block }", verb.line, val)
+			}
+			body, rest, err := parseNodes(rest[1:])
+			if err != nil {
+				return nil, nil, err
+			}
+			// Feed only parses once every brace is closed, so rest starts with }
+			n.Value, n.Body, toks = val, body, rest[1:]
+		case "{":
+			return nil, nil, fmt.Errorf("line %d: { without repeat", verb.line)
+		default:
+			return nil, nil, fmt.Errorf("line %d: unknown command %q", verb.line, verb.text)
+		}
+		out = append(out, n)
+	}
+	return out, toks, nil
+}
+
+// number reads the whole number that must follow verb on the same line.
+func number(verb token, toks []token) (int, []token, error) {
+	if len(toks) == 0 || toks[0].line != verb.line || toks[0].text == "{" || toks[0].text == "}" {
+		return 0, nil, fmt.Errorf("line %d: %s needs a number", verb.line, verb.text)
+	}
+	val, err := strconv.Atoi(toks[0].text)
+	if err != nil {
+		return 0, nil, fmt.Errorf("line %d: %s %q is not a whole number", verb.line, verb.text, toks[0].text)
+	}
+	return val, toks[1:], nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Plan returns the shortest course of forward, back, down and up commands that
+// takes the named model from the surface at 0 to horizontal position horiz and
+// depth depth. Only part1 and part2 can be planned for. The course is replayed
+// through the model before it is returned, so it is known to arrive.
+func Plan(model string, horiz, depth int) ([]Command, error) {
+	var course []Command
+	var err error
+	switch model {
+	case "part1":
+		course = planPart1(horiz, depth)
+	case "part2":
+		course, err = planPart2(horiz, depth)
+	default:
+		return nil, fmt.Errorf("can't plan a course for the %s model", model)
+	}
+	if err != nil {
+		return nil, err
+	}
+	got := Replay(models[model], course)
+	if got.Horiz != horiz || got.Depth != depth {
+		return nil, fmt.Errorf("planned course ends at horizontal %d, depth %d, not %d, %d", got.Horiz, got.Depth, horiz, depth)
+	}
+	return course, nil
+}
+
+// move is the forward or back command that changes horizontal position by v.
+func move(v int) Command {
+	if v < 0 {
+		return Command{Verb: "back", Value: -v}
+	}
+	return Command{Verb: "forward", Value: v}
+}
+
+// vertical is the down or up command that changes depth, or aim, by v.
+func vertical(v int) Command {
+	if v < 0 {
+		return Command{Verb: "up", Value: -v}
+	}
+	return Command{Verb: "down", Value: v}
+}
+
+func planPart1(horiz, depth int) []Command {
+	var out []Command
+	if horiz != 0 {
+		out = append(out, move(horiz))
+	}
+	if depth != 0 {
+		out = append(out, vertical(depth))
+	}
+	return out
+}
+
+// planPart2 finds the shortest course when depth only changes by aim times
+// distance moved. Aim starts at 0, so the course must move with some aim, and
+// the depth is the sum of aim times distance over each move, where back is a
+// negative distance:
+//
+//	no depth:               move horiz
+//	horiz divides depth:    down depth/horiz, move horiz
+//	anything else:          move horiz-f, down depth/f, move f
+//
+// for some f between 0 and horiz that divides depth; f = 1, or -1 when horiz
+// is negative, always does. At horizontal 0 there is nothing between, and f = 1
+// moves back one and forward one again. Each form is only used when the
+// shorter ones can't reach the target, so the course is as short as possible.
+func planPart2(horiz, depth int) ([]Command, error) {
+	switch {
+	case depth == 0:
+		if horiz == 0 {
+			return nil, nil
+		}
+		return []Command{move(horiz)}, nil
+	case horiz != 0 && depth%horiz == 0:
+		return []Command{vertical(depth / horiz), move(horiz)}, nil
+	}
+	// the biggest divisor keeps the aim, and so the dive, gentlest
+	f := horiz - 1
+	switch {
+	case horiz == 0:
+		f = 1
+	case horiz < 0:
+		f = horiz + 1
+	}
+	for depth%f != 0 {
+		if f > 0 {
+			f--
+		} else {
+			f++
+		}
+	}
+	return []Command{move(horiz - f), vertical(depth / f), move(f)}, nil
+}
+
+// Replay runs a planned course through a Sub, the same way a course read from
+// the input would be, and returns where it ends up.
+func Replay(m Model, course []Command) State {
+	sub := NewSub(m)
+	for _, c := range course {
+		sub.Process(c.String())
+	}
+	sub.Finish()
+	return sub.State
+}
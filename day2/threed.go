@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Describer is a Model with more to say about where a course ends than the
+// single number Result gives.
+type Describer interface {
+	Describe(s State) string
+}
+
+// ThreeD steers as well as diving. Turns change the heading, forward and back
+// move along it, and down and up aim the submarine the way they do in Part2.
+// Headings stay on the compass points, so turns must be whole right angles.
+type ThreeD struct{}
+
+func init() {
+	RegisterModel("3d", ThreeD{})
+}
+
+func (ThreeD) Apply(s *State, c Command) error {
+	switch c.Verb {
+	case "forward", "back":
+		v := c.Value
+		if c.Verb == "back" {
+			v = -v
+		}
+		switch s.Heading {
+		case 0:
+			s.Horiz += v
+		case 90:
+			s.Across += v
+		case 180:
+			s.Horiz -= v
+		case 270:
+			s.Across -= v
+		}
+		s.Depth += s.Aim * v
+	case "down":
+		s.Aim += c.Value
+	case "up":
+		s.Aim -= c.Value
+	case "turn":
+		if c.Value%90 != 0 {
+			return fmt.Errorf("can't turn %d degrees, the 3d model only turns in right angles", c.Value)
+		}
+		s.Heading = ((s.Heading+c.Value)%360 + 360) % 360
+	case "surface":
+		s.Depth = 0
+		s.Aim = 0
+	default:
+		return unsupported("3d", c)
+	}
+	return nil
+}
+
+func (ThreeD) Describe(s State) string {
+	manhattan := abs(s.Horiz) + abs(s.Across) + abs(s.Depth)
+	x, y, z := float64(s.Horiz), float64(s.Across), float64(s.Depth)
+	euclid := math.Sqrt(x*x + y*y + z*z)
+	return fmt.Sprintf("ahead %d, right %d, depth %d, heading %d: %d from the start by Manhattan distance, %.3f in a straight line",
+		s.Horiz, s.Across, s.Depth, s.Heading, manhattan, euclid)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Observer is told about every command after the model has applied it. Step
+// counts commands run, so each pass through a repeat block gets new steps.
+type Observer interface {
+	Observe(step int, c Command, s State)
+}
+
+func (c Command) String() string {
+	switch c.Verb {
+	case "surface":
+		return c.Verb
+	case "turn":
+		switch c.Value {
+		case -90:
+			return "turn left"
+		case 90:
+			return "turn right"
+		}
+	}
+	return c.Verb + " " + strconv.Itoa(c.Value)
+}
+
+// Trajectory writes the state after every command as CSV or JSON.
+type Trajectory struct {
+	w    io.Writer
+	csv  *csv.Writer
+	rows int
+	err  error
+}
+
+type trajectoryRow struct {
+	Step    int    `json:"step"`
+	Line    int    `json:"line"`
+	Command string `json:"command"`
+	Horiz   int    `json:"horiz"`
+	Depth   int    `json:"depth"`
+	Aim     int    `json:"aim"`
+	Across  int    `json:"across"`
+	Heading int    `json:"heading"`
+}
+
+// NewTrajectory returns a Trajectory that writes to w in the given format,
+// "csv" or "json".
+func NewTrajectory(w io.Writer, format string) (*Trajectory, error) {
+	t := &Trajectory{w: w}
+	switch format {
+	case "csv":
+		t.csv = csv.NewWriter(w)
+		t.err = t.csv.Write([]string{"step", "line", "command", "horiz", "depth", "aim", "across", "heading"})
+	case "json":
+		_, t.err = io.WriteString(w, "[")
+	default:
+		return nil, fmt.Errorf("unknown trajectory format %q, expected csv or json", format)
+	}
+	return t, nil
+}
+
+func (t *Trajectory) Observe(step int, c Command, s State) {
+	if t.err != nil {
+		return
+	}
+	row := trajectoryRow{
+		Step:    step,
+		Line:    c.Line,
+		Command: c.String(),
+		Horiz:   s.Horiz,
+		Depth:   s.Depth,
+		Aim:     s.Aim,
+		Across:  s.Across,
+		Heading: s.Heading,
+	}
+	if t.csv != nil {
+		t.err = t.csv.Write([]string{
+			strconv.Itoa(row.Step),
+			strconv.Itoa(row.Line),
+			row.Command,
+			strconv.Itoa(row.Horiz),
+			strconv.Itoa(row.Depth),
+			strconv.Itoa(row.Aim),
+			strconv.Itoa(row.Across),
+			strconv.Itoa(row.Heading),
+		})
+		return
+	}
+	b, err := json.Marshal(row)
+	if err != nil {
+		t.err = err
+		return
+	}
+	sep := ",\n  "
+	if t.rows == 0 {
+		sep = "\n  "
+	}
+	t.rows++
+	_, t.err = fmt.Fprintf(t.w, "%s%s", sep, b)
+}
+
+// Close finishes the output and returns the first error seen writing it.
+func (t *Trajectory) Close() error {
+	if t.err != nil {
+		return t.err
+	}
+	if t.csv != nil {
+		t.csv.Flush()
+		return t.csv.Error()
+	}
+	_, err := io.WriteString(t.w, "\n]\n")
+	return err
+}
+
+// Limits are the bounds a safe course stays within. A zero MaxDepth or MaxAim
+// means there is no limit.
+type Limits struct {
+	MaxDepth int
+	MaxAim   int
+	// AllowAboveSurface permits negative depths, which are otherwise a bug in
+	// the course.
+	AllowAboveSurface bool
+}
+
+// Violation is a command that took the submarine outside its limits.
+type Violation struct {
+	Step    int
+	Command Command
+	State   State
+	Reason  string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("line %d, step %d: %v %s (horiz %d, depth %d, aim %d)",
+		v.Command.Line, v.Step, v.Command, v.Reason, v.State.Horiz, v.State.Depth, v.State.Aim)
+}
+
+// Safety checks the state after every command against its Limits and keeps
+// the first violation.
+type Safety struct {
+	Limits
+	First *Violation
+	Count int
+}
+
+func (sf *Safety) check(s State) string {
+	switch {
+	case s.Depth < 0 && !sf.AllowAboveSurface:
+		return fmt.Sprintf("went %d above the surface", -s.Depth)
+	case sf.MaxDepth > 0 && s.Depth > sf.MaxDepth:
+		return fmt.Sprintf("went below the maximum depth of %d", sf.MaxDepth)
+	case sf.MaxAim > 0 && (s.Aim > sf.MaxAim || -s.Aim > sf.MaxAim):
+		return fmt.Sprintf("aimed beyond the maximum of %d", sf.MaxAim)
+	}
+	return ""
+}
+
+func (sf *Safety) Observe(step int, c Command, s State) {
+	reason := sf.check(s)
+	if reason == "" {
+		return
+	}
+	sf.Count++
+	if sf.First == nil {
+		sf.First = &Violation{
+			Step:    step,
+			Command: c,
+			State:   s,
+			Reason:  reason,
+		}
+	}
+}
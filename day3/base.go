@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// Symbols is a diagnostic report written in any base from 2 to 36, such as hex
+// codes. Instead of bits it counts how often each digit appears in each
+// position, and the gamma and epsilon rates are made of the most and least
+// frequent digit of each position. Digits that never appear in a position are
+// counted as appearing zero times, just as a column of all 1s has a least
+// common bit of 0 in binary. Ties go to the higher digit for gamma and the
+// lower one for epsilon, which in base 2 gives the same answer as Part1.
+type Symbols struct {
+	Base int
+	// Pad left-pads numbers with 0s to the width of the widest instead of
+	// rejecting numbers whose width differs from the first.
+	Pad bool
+
+	n      int
+	counts [][]int
+}
+
+func (p *Symbols) Process(s string) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return
+	}
+	s = strings.ToLower(s)
+	s = fitWidth(p.n+1, s, len(p.counts), p.Pad, func(extra int) {
+		cols := make([][]int, extra)
+		for pos := range cols {
+			cols[pos] = make([]int, p.Base)
+			cols[pos][0] = p.n
+		}
+		p.counts = append(cols, p.counts...)
+	})
+	for pos := 0; pos < len(s); pos++ {
+		d := strings.IndexByte(digits[:p.Base], s[pos])
+		if d == -1 {
+			panic(fmt.Errorf("line %d: %q is not a base %d number", p.n+1, s, p.Base))
+		}
+		p.counts[pos][d]++
+	}
+	p.n++
+}
+
+// fitWidth fits number line of a report into the width positions counted so
+// far. The first number sets the width, as does a wider one if pad is set, by
+// calling grow to add the extra leading positions, in which every number so
+// far has a 0. It then returns s as checkWidth does.
+func fitWidth(line int, s string, width int, pad bool, grow func(extra int)) string {
+	if len(s) > width && (pad || line == 1) {
+		grow(len(s) - width)
+		width = len(s)
+	}
+	return checkWidth(line, s, width, pad)
+}
+
+// checkWidth returns s left-padded with 0s to width if pad is set, and panics
+// if it is still the wrong width.
+func checkWidth(line int, s string, width int, pad bool) string {
+	if len(s) < width && pad {
+		s = strings.Repeat("0", width-len(s)) + s
+	}
+	if len(s) != width {
+		panic(fmt.Errorf("line %d: %q is %d digits wide, but the first number is %d; rerun with -pad to pad numbers with 0s to the widest", line, s, len(s), width))
+	}
+	return s
+}
+
+// Power is the gamma rate times the epsilon rate.
+func (p *Symbols) Power() *big.Int {
+	base := big.NewInt(int64(p.Base))
+	gamma, epsilon := new(big.Int), new(big.Int)
+	for _, counts := range p.counts {
+		most, least := 0, 0
+		for d, c := range counts {
+			if c >= counts[most] {
+				most = d
+			}
+			if c < counts[least] {
+				least = d
+			}
+		}
+		gamma.Mul(gamma, base).Add(gamma, big.NewInt(int64(most)))
+		epsilon.Mul(epsilon, base).Add(epsilon, big.NewInt(int64(least)))
+	}
+	return gamma.Mul(gamma, epsilon)
+}
+
+func (p *Symbols) Result() int {
+	return int(p.Power().Int64())
+}
+
+// padAll left-pads every number in bits with 0s to the width of the widest.
+func padAll(bits []string) []string {
+	width := 0
+	for _, v := range bits {
+		if len(v) > width {
+			width = len(v)
+		}
+	}
+	out := make([]string, len(bits))
+	for i, v := range bits {
+		out[i] = checkWidth(i+1, v, width, true)
+	}
+	return out
+}
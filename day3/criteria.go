@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Criterion is a rule for narrowing a report down to a single rating: which
+// bit to keep at each position, what to do when 0s and 1s are equally common,
+// and which end of the numbers to start from.
+type Criterion struct {
+	// Least keeps the least common bit instead of the most common.
+	Least bool
+	Tie   Tie
+	// LSBFirst looks at the last bit first instead of the first.
+	LSBFirst bool
+}
+
+// Tie says which bit to keep when there are as many 1s as 0s.
+type Tie int
+
+const (
+	PreferOne Tie = iota
+	PreferZero
+	// ErrorOnTie stops the search instead of picking a side.
+	ErrorOnTie
+)
+
+var ties = map[string]Tie{
+	"prefer1": PreferOne,
+	"prefer0": PreferZero,
+	"error":   ErrorOnTie,
+}
+
+func (t Tie) String() string {
+	for k, v := range ties {
+		if v == t {
+			return k
+		}
+	}
+	return fmt.Sprintf("Tie(%d)", int(t))
+}
+
+var (
+	// Oxygen is the oxygen generator rating's criterion from the puzzle.
+	Oxygen = Criterion{Tie: PreferOne}
+	// CO2 is the CO2 scrubber rating's criterion from the puzzle.
+	CO2 = Criterion{Least: true, Tie: PreferZero}
+)
+
+func (c Criterion) String() string {
+	common, order := "most", "msb"
+	if c.Least {
+		common = "least"
+	}
+	if c.LSBFirst {
+		order = "lsb"
+	}
+	return common + "," + c.Tie.String() + "," + order
+}
+
+// ParseCriterion reads a criterion written as comma separated words: most or
+// least, then optionally prefer1, prefer0 or error for ties, and msb or lsb for
+// the order. Ties default to prefer1 for most and prefer0 for least, as in the
+// puzzle, and the order to msb.
+func ParseCriterion(s string) (Criterion, error) {
+	var c Criterion
+	words := strings.Split(s, ",")
+	switch words[0] {
+	case "most":
+	case "least":
+		c.Least = true
+		c.Tie = PreferZero
+	default:
+		return c, fmt.Errorf("criterion %q must start with most or least", s)
+	}
+	for _, w := range words[1:] {
+		if t, ok := ties[w]; ok {
+			c.Tie = t
+			continue
+		}
+		switch w {
+		case "msb":
+			c.LSBFirst = false
+		case "lsb":
+			c.LSBFirst = true
+		default:
+			return c, fmt.Errorf("criterion %q: unknown word %q, expected prefer1, prefer0, error, msb or lsb", s, w)
+		}
+	}
+	return c, nil
+}
+
+// Choose returns the bit to keep when ones of total candidates have a 1 in the
+// position being looked at.
+func (c Criterion) Choose(ones, total int) (byte, error) {
+	zeros := total - ones
+	if ones == zeros {
+		switch c.Tie {
+		case PreferOne:
+			return '1', nil
+		case PreferZero:
+			return '0', nil
+		}
+		return 0, fmt.Errorf("%d candidates are split evenly between 0 and 1", total)
+	}
+	if (ones > zeros) != c.Least {
+		return '1', nil
+	}
+	return '0', nil
+}
+
+// Position returns the position looked at in round i of a search through
+// numbers width bits wide.
+func (c Criterion) Position(i, width int) int {
+	if c.LSBFirst {
+		return width - 1 - i
+	}
+	return i
+}
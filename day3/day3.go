@@ -1,15 +1,104 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
+	"math/big"
 	"os"
+	"sort"
 	"strconv"
+
+	"advent-2021/difftest"
+	"advent-2021/input"
+)
+
+var (
+	pad  = flag.Bool("pad", false, "left-pad numbers with 0s to the width of the widest instead of rejecting them")
+	base = flag.Int("base", 2, "base the diagnostic codes are written in, from 2 to 36")
 )
 
 func main() {
-	process(&Part1{})
-	process(&Part2{})
+	flag.Parse()
+	if *base < 2 || *base > len(digits) {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if flag.Arg(0) == "diff" {
+		difftest.Main(flag.Args()[1:], lifeSupportProperty, packedPowerProperty, packedLifeSupportProperty, symbolsPowerProperty)
+		return
+	}
+	if flag.Arg(0) == "rate" {
+		fs := flag.NewFlagSet("rate", flag.ExitOnError)
+		criteria := criteriaFlags(fs)
+		fs.Parse(flag.Args()[1:])
+		c1, c2 := criteria()
+		p := &Packed{Pad: *pad}
+		load(p)
+		product := big.NewInt(1)
+		for _, c := range []Criterion{c1, c2} {
+			r, err := p.Rating(c)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%v: %v\n", c, err)
+				os.Exit(1)
+			}
+			fmt.Printf("%v: %v\n", c, r)
+			product.Mul(product, r)
+		}
+		fmt.Println(product)
+		return
+	}
+	if flag.Arg(0) == "explain" {
+		fs := flag.NewFlagSet("explain", flag.ExitOnError)
+		criteria := criteriaFlags(fs)
+		max := fs.Int("list", 8, "most survivors to list for each round")
+		fs.Parse(flag.Args()[1:])
+		c1, c2 := criteria()
+		p := &Part2{}
+		load(p)
+		bits := p.bits
+		if *pad {
+			bits = padAll(bits)
+		}
+		Explain(bits, c1).Print(os.Stdout, *max)
+		fmt.Println()
+		Explain(bits, c2).Print(os.Stdout, *max)
+		return
+	}
+	if *base != 2 {
+		// the ratings only make sense for bits, but the power does for any base
+		p := &Symbols{Base: *base, Pad: *pad}
+		load(p)
+		fmt.Println(p.Power())
+		return
+	}
+	p := &Packed{Pad: *pad}
+	load(p)
+	fmt.Println(p.Power())
+	lifeSupport, err := p.LifeSupport()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(lifeSupport)
+}
+
+// criteriaFlags adds the flags for choosing the two rating criteria to fs, and
+// returns a function to parse them once fs has been parsed.
+func criteriaFlags(fs *flag.FlagSet) func() (Criterion, Criterion) {
+	o2 := fs.String("o2", Oxygen.String(), "criterion for the first rating: most or least, then optionally prefer1, prefer0 or error for ties, and msb or lsb")
+	co2 := fs.String("co2", CO2.String(), "criterion for the second rating, written the same way")
+	return func() (Criterion, Criterion) {
+		c1, err := ParseCriterion(*o2)
+		if err == nil {
+			var c2 Criterion
+			if c2, err = ParseCriterion(*co2); err == nil {
+				return c1, c2
+			}
+		}
+		fmt.Fprintln(os.Stderr, err)
+		fs.Usage()
+		os.Exit(2)
+		return Criterion{}, Criterion{}
+	}
 }
 
 /*
@@ -62,7 +151,8 @@ func (p *Part1) Result() int {
 	for _, v := range p.onesCount {
 		gamma = gamma * 2
 		epsilon = epsilon * 2
-		if v > p.total/2 {
+		// a tie counts as 1 being the most common, as in the oxygen rating
+		if 2*v >= p.total {
 			gamma++
 		} else {
 			epsilon++
@@ -96,27 +186,46 @@ func (p *Part2) Process(s string) {
 }
 
 func (p *Part2) Result() int {
-	o2 := p.Find('1', '0')
-	co2 := p.Find('0', '1')
+	v, err := p.LifeSupport()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// LifeSupport is the oxygen generator rating times the CO2 scrubber rating.
+func (p *Part2) LifeSupport() (int, error) {
+	o2, err := p.Find(Oxygen)
+	if err != nil {
+		return 0, fmt.Errorf("%v: %w", Oxygen, err)
+	}
+	co2, err := p.Find(CO2)
+	if err != nil {
+		return 0, fmt.Errorf("%v: %w", CO2, err)
+	}
 	o2Level, _ := strconv.ParseInt(o2, 2, 64)
 	co2Level, _ := strconv.ParseInt(co2, 2, 64)
-	return int(o2Level * co2Level)
+	return int(o2Level * co2Level), nil
 }
 
-func (p *Part2) Find(gt byte, lt byte) string {
+// Find narrows the report down to one number using criterion c. It fails if c
+// won't break a tie, or if a round leaves no candidates.
+func (p *Part2) Find(c Criterion) (string, error) {
 	o2s := p.bits
-	pos := 0
-	for len(o2s) > 1 && pos < len(o2s[0]) {
+	for i := 0; len(o2s) > 1 && i < len(o2s[0]); i++ {
+		pos := c.Position(i, len(o2s[0]))
 		onesCount := buildOnesCount(o2s, pos)
-		var o2Check = gt
-		if onesCount*2 < len(o2s) {
-			o2Check = lt
+		o2Check, err := c.Choose(onesCount, len(o2s))
+		if err != nil {
+			return "", fmt.Errorf("position %d: %w", pos, err)
 		}
 		// keep entries from o2s with a o2Check in the ith position
 		o2s = filter(pos, o2Check, o2s)
-		pos++
+		if len(o2s) == 0 {
+			return "", fmt.Errorf("no candidates left with %c in position %d", o2Check, pos)
+		}
 	}
-	return o2s[0]
+	return o2s[0], nil
 }
 
 func buildOnesCount(s []string, pos int) int {
@@ -139,23 +248,73 @@ func filter(pos int, ch byte, s []string) []string {
 	return newO2s
 }
 
+// lifeSupport computes the same rating as Part2 without copying the candidates
+// on every round. Once the report is sorted, the numbers that share the bits
+// chosen so far are a contiguous range, and the ones with a 1 in the next
+// position are the tail of that range, so each round is a binary search. That
+// only holds when the search starts from the first bit, so findSorted can't
+// take an lsb criterion.
+func lifeSupport(bits []string) (int, error) {
+	sorted := append([]string(nil), bits...)
+	sort.Strings(sorted)
+	o2, err := findSorted(sorted, Oxygen)
+	if err != nil {
+		return 0, fmt.Errorf("%v: %w", Oxygen, err)
+	}
+	co2, err := findSorted(sorted, CO2)
+	if err != nil {
+		return 0, fmt.Errorf("%v: %w", CO2, err)
+	}
+	o2Level, _ := strconv.ParseInt(o2, 2, 64)
+	co2Level, _ := strconv.ParseInt(co2, 2, 64)
+	return int(o2Level * co2Level), nil
+}
+
+func findSorted(sorted []string, c Criterion) (string, error) {
+	if c.LSBFirst {
+		panic("findSorted can only search from the first bit")
+	}
+	lo, hi := 0, len(sorted)
+	pos := 0
+	for hi-lo > 1 && pos < len(sorted[lo]) {
+		split := lo + sort.Search(hi-lo, func(i int) bool {
+			return sorted[lo+i][pos] == '1'
+		})
+		check, err := c.Choose(hi-split, hi-lo)
+		if err != nil {
+			return "", fmt.Errorf("position %d: %w", pos, err)
+		}
+		if check == '1' {
+			lo = split
+		} else {
+			hi = split
+		}
+		if lo == hi {
+			return "", fmt.Errorf("no candidates left with %c in position %d", check, pos)
+		}
+		pos++
+	}
+	return sorted[lo], nil
+}
+
 type Processor interface {
 	Process(s string)
 	Result() int
 }
 
-func process(p Processor) {
+// load runs the whole input through p.
+func load(p Processor) {
 	f, err := os.Open("./day3/input.txt")
 	if err != nil {
 		panic(err)
 	}
 	defer f.Close()
-	scanner := bufio.NewScanner(f)
-
-	scanner.Split(bufio.ScanLines)
+	scanner := input.Lines(f, "./day3/input.txt")
 
 	for scanner.Scan() {
 		p.Process(scanner.Text())
 	}
-	fmt.Println(p.Result())
+	if err := scanner.Err(); err != nil {
+		panic(err)
+	}
 }
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"math/rand"
+
+	"advent-2021/difftest"
+)
+
+// lifeSupportProperty checks lifeSupport against Part2.
+var lifeSupportProperty = difftest.Property{
+	Name:     "day3 Part2 vs lifeSupport",
+	Generate: generateReport,
+	Shrink:   shrinkReport,
+	Reference: func(v interface{}) interface{} {
+		return outcome(part2(v.([]string)).LifeSupport())
+	},
+	Optimized: func(v interface{}) interface{} {
+		return outcome(lifeSupport(v.([]string)))
+	},
+}
+
+// packedPowerProperty checks the packed power consumption against Part1.
+var packedPowerProperty = difftest.Property{
+	Name:     "day3 Part1 vs Packed",
+	Generate: generateReport,
+	Shrink:   shrinkReport,
+	Reference: func(v interface{}) interface{} {
+		p := &Part1{}
+		for _, line := range v.([]string) {
+			p.Process(line)
+		}
+		return p.Result()
+	},
+	Optimized: func(v interface{}) interface{} {
+		return packed(v.([]string)).Result()
+	},
+}
+
+// packedLifeSupportProperty checks the packed rating search against Part2.
+var packedLifeSupportProperty = difftest.Property{
+	Name:     "day3 Part2 vs Packed",
+	Generate: generateReport,
+	Shrink:   shrinkReport,
+	Reference: func(v interface{}) interface{} {
+		return outcome(part2(v.([]string)).LifeSupport())
+	},
+	Optimized: func(v interface{}) interface{} {
+		n, err := packed(v.([]string)).LifeSupport()
+		if err != nil {
+			return err.Error()
+		}
+		return int(n.Int64())
+	},
+}
+
+// symbolsPowerProperty checks the power counted by digit in base 2 against
+// Part1.
+var symbolsPowerProperty = difftest.Property{
+	Name:     "day3 Part1 vs Symbols",
+	Generate: generateReport,
+	Shrink:   shrinkReport,
+	Reference: func(v interface{}) interface{} {
+		p := &Part1{}
+		for _, line := range v.([]string) {
+			p.Process(line)
+		}
+		return p.Result()
+	},
+	Optimized: func(v interface{}) interface{} {
+		p := &Symbols{Base: 2}
+		for _, line := range v.([]string) {
+			p.Process(line)
+		}
+		return p.Result()
+	},
+}
+
+func part2(report []string) *Part2 {
+	p := &Part2{}
+	for _, line := range report {
+		p.Process(line)
+	}
+	return p
+}
+
+// outcome is a life support rating, or the error that stopped the search, in a
+// form the implementations can be compared by.
+func outcome(n int, err error) interface{} {
+	if err != nil {
+		return err.Error()
+	}
+	return n
+}
+
+func packed(report []string) *Packed {
+	p := &Packed{}
+	for _, line := range report {
+		p.Process(line)
+	}
+	return p
+}
+
+// generateReport builds a random report. Every number in it has the same
+// width, as in the puzzle input.
+func generateReport(r *rand.Rand) interface{} {
+	width := r.Intn(8) + 1
+	var report []string
+	for i := r.Intn(20) + 1; i > 0; i-- {
+		line := make([]byte, width)
+		for j := range line {
+			line[j] = byte('0' + r.Intn(2))
+		}
+		report = append(report, string(line))
+	}
+	return report
+}
+
+func shrinkReport(v interface{}) []interface{} {
+	report := v.([]string)
+	var out []interface{}
+	for _, smaller := range difftest.ShrinkStrings(report) {
+		if len(smaller) > 0 {
+			out = append(out, smaller)
+		}
+	}
+	// drop the last column
+	if len(report[0]) > 1 {
+		narrower := make([]string, len(report))
+		for i, line := range report {
+			narrower[i] = line[:len(line)-1]
+		}
+		out = append(out, narrower)
+	}
+	return out
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Step is one round of a rating search: the candidates going into it, how
+// many of them have a 1 in position Pos, which bit was kept and what survived.
+// Problem is set, and the search stopped, if the round could not be finished.
+type Step struct {
+	Pos        int
+	Candidates int
+	Ones       int
+	Zeros      int
+	Keep       byte
+	Survivors  []string
+	Problem    string
+}
+
+// Explanation is a rating search written out round by round.
+type Explanation struct {
+	Criterion Criterion
+	Steps     []Step
+	// Rating is the number the search ended on, or empty if it failed.
+	Rating string
+}
+
+// Explain runs the same search as Part2.Find, but records each round, and
+// reports the cases that stop Find as the round they happened in: numbers too
+// short to have a bit in the position being looked at, ties the criterion
+// won't break, and rounds that leave no candidates at all.
+func Explain(bits []string, c Criterion) Explanation {
+	e := Explanation{Criterion: c}
+	if len(bits) == 0 {
+		e.Steps = append(e.Steps, Step{Problem: "the report is empty"})
+		return e
+	}
+	width := len(bits[0])
+	for _, v := range bits {
+		if len(v) > width {
+			width = len(v)
+		}
+	}
+	cands := bits
+	for i := 0; len(cands) > 1 && i < width; i++ {
+		pos := c.Position(i, width)
+		step := Step{Pos: pos, Candidates: len(cands)}
+		short := 0
+		for _, v := range cands {
+			if pos >= len(v) {
+				short++
+			} else if v[pos] == '1' {
+				step.Ones++
+			} else {
+				step.Zeros++
+			}
+		}
+		if short > 0 {
+			step.Problem = fmt.Sprintf("%d of %d candidates are too short to have a bit in position %d; the numbers in the report have different widths", short, len(cands), pos)
+			e.Steps = append(e.Steps, step)
+			return e
+		}
+		keep, err := c.Choose(step.Ones, len(cands))
+		if err != nil {
+			step.Problem = err.Error()
+			e.Steps = append(e.Steps, step)
+			return e
+		}
+		step.Keep = keep
+		step.Survivors = filter(pos, keep, cands)
+		e.Steps = append(e.Steps, step)
+		if len(step.Survivors) == 0 {
+			e.Steps[len(e.Steps)-1].Problem = fmt.Sprintf("every candidate was removed, none has a %c in position %d", keep, pos)
+			return e
+		}
+		cands = step.Survivors
+	}
+	e.Rating = cands[0]
+	return e
+}
+
+// Print writes the explanation as a table, listing at most max survivors of
+// each round.
+func (e Explanation) Print(w io.Writer, max int) {
+	fmt.Fprintf(w, "%v:\n", e.Criterion)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "pos\tcandidates\tones\tzeros\tkeep\tsurvivors\tdetail")
+	for _, s := range e.Steps {
+		keep, survivors := "-", "-"
+		if s.Keep != 0 {
+			keep, survivors = string(s.Keep), fmt.Sprint(len(s.Survivors))
+		}
+		detail := s.Problem
+		if detail == "" {
+			detail = listed(s.Survivors, max)
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%s\t%s\t%s\n", s.Pos, s.Candidates, s.Ones, s.Zeros, keep, survivors, detail)
+	}
+	tw.Flush()
+	if e.Rating != "" {
+		fmt.Fprintf(w, "rating: %s\n", e.Rating)
+	} else {
+		fmt.Fprintln(w, "no rating")
+	}
+}
+
+func listed(vals []string, max int) string {
+	if len(vals) <= max {
+		return strings.Join(vals, " ")
+	}
+	return fmt.Sprintf("%s ... and %d more", strings.Join(vals[:max], " "), len(vals)-max)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+	"strings"
+)
+
+// Packed holds a diagnostic report as bits rather than strings. It is stored a
+// column at a time: column pos is a bitset with bit i set if number i has a 1
+// in position pos, packed into uint64 words. Counting the ones in a column is
+// then a popcount per word, and the candidates left in a rating search are one
+// more bitset, so each round of the search is a few word-wide ANDs.
+//
+// Nothing limits how wide the numbers are, so the results are big.Ints.
+type Packed struct {
+	// Pad is as for Symbols.
+	Pad bool
+
+	width int
+	n     int
+	cols  [][]uint64
+}
+
+func (p *Packed) Process(s string) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return
+	}
+	s = fitWidth(p.n+1, s, p.width, p.Pad, func(extra int) {
+		cols := make([][]uint64, extra)
+		for pos := range cols {
+			cols[pos] = make([]uint64, (p.n+63)/64)
+		}
+		p.cols = append(cols, p.cols...)
+		p.width += extra
+	})
+	word, bit := p.n/64, uint64(1)<<(p.n%64)
+	if bit == 1 {
+		for pos := range p.cols {
+			p.cols[pos] = append(p.cols[pos], 0)
+		}
+	}
+	for pos := 0; pos < len(s); pos++ {
+		switch s[pos] {
+		case '1':
+			p.cols[pos][word] |= bit
+		case '0':
+		default:
+			panic(fmt.Errorf("line %d: %q is not binary", p.n+1, s))
+		}
+	}
+	p.n++
+}
+
+// Result is the power consumption, for comparing with Part1.
+func (p *Packed) Result() int {
+	return int(p.Power().Int64())
+}
+
+// all returns a bitset with every number in the report in it.
+func (p *Packed) all() []uint64 {
+	set := make([]uint64, (p.n+63)/64)
+	for i := range set {
+		set[i] = ^uint64(0)
+	}
+	if extra := p.n % 64; extra != 0 {
+		set[len(set)-1] = uint64(1)<<extra - 1
+	}
+	return set
+}
+
+func count(set []uint64) int {
+	var out int
+	for _, w := range set {
+		out += bits.OnesCount64(w)
+	}
+	return out
+}
+
+// onesIn counts the numbers in set with a 1 in position pos.
+func (p *Packed) onesIn(set []uint64, pos int) int {
+	var out int
+	for i, w := range set {
+		out += bits.OnesCount64(w & p.cols[pos][i])
+	}
+	return out
+}
+
+// Power is gamma times epsilon. A 1 in gamma means at least half the numbers
+// have a 1 there, so ties go to 1 as they do for the oxygen rating.
+func (p *Packed) Power() *big.Int {
+	all := p.all()
+	gamma, epsilon := new(big.Int), new(big.Int)
+	for pos := range p.cols {
+		gamma.Lsh(gamma, 1)
+		epsilon.Lsh(epsilon, 1)
+		if 2*p.onesIn(all, pos) >= p.n {
+			gamma.SetBit(gamma, 0, 1)
+		} else {
+			epsilon.SetBit(epsilon, 0, 1)
+		}
+	}
+	return gamma.Mul(gamma, epsilon)
+}
+
+// Rating narrows the report down to one number using criterion c and returns
+// that number. It fails if c won't break a tie, or if a round leaves no
+// candidates.
+func (p *Packed) Rating(c Criterion) (*big.Int, error) {
+	cands := p.all()
+	left := p.n
+	for i := 0; left > 1 && i < p.width; i++ {
+		pos := c.Position(i, p.width)
+		ones := p.onesIn(cands, pos)
+		check, err := c.Choose(ones, left)
+		if err != nil {
+			return nil, fmt.Errorf("position %d: %w", pos, err)
+		}
+		col := p.cols[pos]
+		for w := range cands {
+			if check == '1' {
+				cands[w] &= col[w]
+			} else {
+				cands[w] &^= col[w]
+			}
+		}
+		if check == '1' {
+			left = ones
+		} else {
+			left -= ones
+		}
+		if left == 0 {
+			return nil, fmt.Errorf("no candidates left with %c in position %d", check, pos)
+		}
+	}
+	return p.number(first(cands)), nil
+}
+
+// first returns the index of the lowest number in set.
+func first(set []uint64) int {
+	for i, w := range set {
+		if w != 0 {
+			return i*64 + bits.TrailingZeros64(w)
+		}
+	}
+	panic("no numbers in the report")
+}
+
+// number unpacks number i of the report.
+func (p *Packed) number(i int) *big.Int {
+	out := new(big.Int)
+	word, bit := i/64, uint64(1)<<(i%64)
+	for pos := range p.cols {
+		out.Lsh(out, 1)
+		if p.cols[pos][word]&bit != 0 {
+			out.SetBit(out, 0, 1)
+		}
+	}
+	return out
+}
+
+// LifeSupport is the oxygen generator rating times the CO2 scrubber rating.
+func (p *Packed) LifeSupport() (*big.Int, error) {
+	o2, err := p.Rating(Oxygen)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", Oxygen, err)
+	}
+	co2, err := p.Rating(CO2)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", CO2, err)
+	}
+	return o2.Mul(o2, co2), nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"text/tabwriter"
+)
+
+// Odds is how a board fared over many games with the numbers drawn in random
+// orders.
+type Odds struct {
+	Board int
+	// First and Last count the games the board won first and last by how
+	// many boards it tied with: First[k] is the games it won first on the
+	// same draw as k-1 others. Keeping counts rather than shares keeps the
+	// totals exact, whatever order the games are added up in.
+	First []int
+	Last  []int
+	// Won counts the games the board won at all, and HalfPlaces and Scores
+	// add up twice where it placed, counting from 1, and what it scored in
+	// them. Tied boards share the places they cover, so two boards tying for
+	// first both place 1.5, adding 3.
+	Won        int
+	HalfPlaces int
+	Scores     int
+}
+
+// FirstShare is the number of games the board won first, with each of k boards
+// that tie for first credited with 1/k of a game.
+func (o Odds) FirstShare() float64 {
+	return share(o.First)
+}
+
+// LastShare is the number of games the board won last, shared as FirstShare.
+func (o Odds) LastShare() float64 {
+	return share(o.Last)
+}
+
+func share(byTie []int) float64 {
+	var n float64
+	for k, games := range byTie {
+		if games > 0 {
+			n += float64(games) / float64(k)
+		}
+	}
+	return n
+}
+
+// MeanPlace is the board's mean place over the games it won, which may be only
+// a few; Won says how many.
+func (o Odds) MeanPlace() float64 {
+	if o.Won == 0 {
+		return 0
+	}
+	return float64(o.HalfPlaces) / float64(2*o.Won)
+}
+
+func (o Odds) MeanScore() float64 {
+	if o.Won == 0 {
+		return 0
+	}
+	return float64(o.Scores) / float64(o.Won)
+}
+
+// Advice is the result of simulating many games.
+type Advice struct {
+	Trials int
+	Odds   []Odds
+}
+
+// Advise plays trials games, each with the numbers shuffled into a different
+// order, spread across workers goroutines. Game t shuffles with a source
+// seeded by seed+t, so the advice depends only on seed and not on how the
+// games were shared out.
+func Advise(numbers []string, boards []board, lines []Line, trials int, seed int64, workers int) Advice {
+	if workers < 1 {
+		workers = 1
+	}
+	results := make(chan []Odds, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			odds := newOdds(len(boards))
+			order := make([]string, len(numbers))
+			for t := w; t < trials; t += workers {
+				copy(order, numbers)
+				r := rand.New(rand.NewSource(seed + int64(t)))
+				r.Shuffle(len(order), func(i, j int) {
+					order[i], order[j] = order[j], order[i]
+				})
+				g := NewGame(boards, lines)
+				g.Play(order)
+				credit(odds, g.Finishes)
+			}
+			results <- odds
+		}(w)
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	a := Advice{Trials: trials, Odds: newOdds(len(boards))}
+	for odds := range results {
+		for p, o := range odds {
+			sum := &a.Odds[p]
+			for k := range o.First {
+				sum.First[k] += o.First[k]
+				sum.Last[k] += o.Last[k]
+			}
+			sum.Won += o.Won
+			sum.HalfPlaces += o.HalfPlaces
+			sum.Scores += o.Scores
+		}
+	}
+	return a
+}
+
+// newOdds returns empty odds for n boards, with room to count ties between
+// all of them.
+func newOdds(n int) []Odds {
+	odds := make([]Odds, n)
+	for p := range odds {
+		odds[p] = Odds{Board: p, First: make([]int, n+1), Last: make([]int, n+1)}
+	}
+	return odds
+}
+
+// credit adds up how the boards in one game finished. Finishes are in board
+// order within a draw, so boards that won on the same draw are taken together
+// and share the places they cover.
+func credit(odds []Odds, fs []Finish) {
+	for k := 0; k < len(fs); {
+		e := k + 1
+		for e < len(fs) && fs[e].Draw == fs[k].Draw {
+			e++
+		}
+		tied := e - k
+		for _, f := range fs[k:e] {
+			o := &odds[f.Board]
+			o.Won++
+			// twice the mean of places k+1 to e
+			o.HalfPlaces += k + 1 + e
+			o.Scores += f.Score
+			if k == 0 {
+				o.First[tied]++
+			}
+			if e == len(fs) {
+				o.Last[tied]++
+			}
+		}
+		k = e
+	}
+}
+
+// Best returns the board most likely to win first, or last if last is set.
+func (a Advice) Best(last bool) Odds {
+	chance := Odds.FirstShare
+	if last {
+		chance = Odds.LastShare
+	}
+	best := a.Odds[0]
+	for _, o := range a.Odds[1:] {
+		if chance(o) > chance(best) {
+			best = o
+		}
+	}
+	return best
+}
+
+func (a Advice) Print(w io.Writer, last bool) {
+	n := float64(a.Trials)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "board\tP(first)\tP(last)\tP(win)\tgames won\tmean place\tmean score")
+	for _, o := range a.Odds {
+		fmt.Fprintf(tw, "%d\t%.3f\t%.3f\t%.3f\t%d\t%.2f\t%.1f\n",
+			o.Board, o.FirstShare()/n, o.LastShare()/n, float64(o.Won)/n, o.Won, o.MeanPlace(), o.MeanScore())
+	}
+	tw.Flush()
+	best := a.Best(last)
+	goal, p := "first", best.FirstShare()
+	if last {
+		goal, p = "last", best.LastShare()
+	}
+	fmt.Fprintf(w, "over %d games, pick board %d to win %s: it did in %.1f%% of them, counting ties as shared\n", a.Trials, best.Board, goal, 100*p/n)
+}
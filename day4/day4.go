@@ -1,18 +1,157 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
+	"math/rand"
+	"net"
 	"os"
+	"runtime"
 	"strconv"
 	"strings"
+	"time"
+
+	"advent-2021/eventlog"
+	"advent-2021/input"
+	"advent-2021/repl"
 )
 
 func main() {
-	part1()
-	part2()
+	flag.Parse()
+	defer events.MustClose()
+	if flag.Arg(0) == "repl" {
+		numbers, boards, lines := getData()
+		if err := repl.Run("day4", newBingoSim(numbers, boards, lines), os.Stdin, os.Stdout); err != nil {
+			panic(err)
+		}
+		return
+	}
+	if flag.Arg(0) == "advise" {
+		fs := flag.NewFlagSet("advise", flag.ExitOnError)
+		trials := fs.Int("trials", 10000, "number of games to simulate")
+		seed := fs.Int64("seed", 1, "seed for shuffling the numbers")
+		workers := fs.Int("workers", runtime.NumCPU(), "number of goroutines to simulate with")
+		last := fs.Bool("last", false, "pick the board most likely to win last, as part 2 does")
+		fs.Parse(flag.Args()[1:])
+		if *trials < 1 {
+			fs.Usage()
+			os.Exit(2)
+		}
+		numbers, boards, lines := getData()
+		Advise(numbers, boards, lines, *trials, *seed, *workers).Print(os.Stdout, *last)
+		return
+	}
+	if flag.Arg(0) == "serve" {
+		fs := flag.NewFlagSet("serve", flag.ExitOnError)
+		addr := fs.String("addr", "127.0.0.1:7704", "address to listen on")
+		players := fs.Int("players", 2, "number of players to wait for")
+		interval := fs.Duration("interval", time.Second, "time between draws")
+		seed := fs.Int64("seed", 0, "if not 0, shuffle the numbers with this seed instead of drawing them in input order")
+		fs.Parse(flag.Args()[1:])
+		numbers, boards, lines := getData()
+		if *seed != 0 {
+			r := rand.New(rand.NewSource(*seed))
+			r.Shuffle(len(numbers), func(i, j int) {
+				numbers[i], numbers[j] = numbers[j], numbers[i]
+			})
+		}
+		srv, err := NewBingoServer(numbers, boards, lines, *players, *interval)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			fs.Usage()
+			os.Exit(2)
+		}
+		l, err := net.Listen("tcp", *addr)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Fprintf(os.Stderr, "waiting for %d players on %s\n", *players, l.Addr())
+		if err := srv.Serve(l); err != nil {
+			panic(err)
+		}
+		return
+	}
+	if flag.Arg(0) == "client" {
+		fs := flag.NewFlagSet("client", flag.ExitOnError)
+		addr := fs.String("addr", "127.0.0.1:7704", "address of the server")
+		name := fs.String("name", "player", "name to join the game with")
+		fs.Parse(flag.Args()[1:])
+		conn, err := net.Dial("tcp", *addr)
+		if err != nil {
+			panic(err)
+		}
+		defer conn.Close()
+		if err := Client(conn, *name, os.Stdout); err != nil {
+			panic(err)
+		}
+		return
+	}
+	if flag.Arg(0) == "generate" {
+		fs := flag.NewFlagSet("generate", flag.ExitOnError)
+		spec := GenSpec{FirstBoard: -1}
+		fs.IntVar(&spec.Boards, "boards", 100, "number of boards")
+		fs.IntVar(&spec.Rows, "rows", 5, "rows on each board")
+		fs.IntVar(&spec.Cols, "cols", 5, "columns on each board")
+		fs.IntVar(&spec.Min, "min", 0, "smallest number")
+		fs.IntVar(&spec.Max, "max", 99, "largest number")
+		first := fs.String("first", "", "BOARD@DRAW: make board BOARD the first to win, on draw DRAW counting from 1")
+		fs.BoolVar(&spec.Distinct, "distinct", false, "make every board win on a different draw")
+		seed := fs.Int64("seed", 1, "seed for the random game")
+		attempts := fs.Int("attempts", 1000, "games to try before giving up")
+		out := fs.String("o", "", "file to write the game to (default stdout)")
+		fs.Parse(flag.Args()[1:])
+		if *first != "" {
+			parts := strings.Split(*first, "@")
+			var err1, err2 error
+			if len(parts) == 2 {
+				spec.FirstBoard, err1 = strconv.Atoi(parts[0])
+				spec.FirstDraw, err2 = strconv.Atoi(parts[1])
+			}
+			if len(parts) != 2 || err1 != nil || err2 != nil || spec.FirstBoard < 0 {
+				fs.Usage()
+				os.Exit(2)
+			}
+		}
+		lines, err := winLines(*winFlag, spec.Rows, spec.Cols)
+		if err != nil {
+			panic(fmt.Errorf("-win: %w", err))
+		}
+		numbers, boards, err := Generate(spec, lines, rand.New(rand.NewSource(*seed)), *attempts)
+		if err != nil {
+			panic(err)
+		}
+		w := os.Stdout
+		if *out != "" {
+			f, err := os.Create(*out)
+			if err != nil {
+				panic(err)
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := writeGame(w, numbers, boards); err != nil {
+			panic(err)
+		}
+		return
+	}
+	if flag.Arg(0) == "order" {
+		g := play()
+		g.Print(os.Stdout)
+		if f, ok := g.First(); ok {
+			fmt.Println("first:", f.Score)
+		}
+		if f, ok := g.Last(); ok {
+			fmt.Println("last:", f.Score)
+		}
+		return
+	}
+	g := play()
+	part1(g)
+	part2(g)
 }
 
+var events = eventlog.FromFlag()
+
 /*
 Bingo is played on a set of boards each consisting of a 5x5 grid of numbers. Numbers are chosen at random, and the chosen number is marked on all boards on which it appears. (Numbers may not appear on all boards.) If all numbers in any row or any column of a board are marked, that board wins. (Diagonals don't count.)
 
@@ -65,13 +204,15 @@ The score of the winning board can now be calculated. Start by finding the sum o
 To guarantee victory against the giant squid, figure out which board will win first. What will your final score be if you choose that board?
 */
 
+// board holds a card's numbers, a row at a time. Every board in a game has the
+// same number of rows and columns, but not necessarily 5 of each.
 type board [][]string
 
 func (b board) score(bs boardstate, lastNum int) int {
 	sum := 0
-	for i := 0; i < 5; i++ {
-		for j := 0; j < 5; j++ {
-			if !bs[i][j] {
+	for i := range b {
+		for j := range b[i] {
+			if !bs.marked[i][j] {
 				n, _ := strconv.Atoi(b[i][j])
 				sum += n
 			}
@@ -81,8 +222,8 @@ func (b board) score(bs boardstate, lastNum int) int {
 }
 
 func (b board) contains(num string) (int, int) {
-	for i := 0; i < 5; i++ {
-		for j := 0; j < 5; j++ {
+	for i := range b {
+		for j := range b[i] {
 			if b[i][j] == num {
 				return i, j
 			}
@@ -91,113 +232,171 @@ func (b board) contains(num string) (int, int) {
 	return -1, -1
 }
 
-type boardstate [5][5]bool
+// boardstate is the cells of a board that have been marked, and the lines that
+// win it.
+type boardstate struct {
+	marked [][]bool
+	lines  []Line
+}
 
-func (bs boardstate) won() bool {
-	// check rows
-	for i := 0; i < 5; i++ {
-		won := true
-		for j := 0; j < 5; j++ {
-			if !bs[i][j] {
-				won = false
-				break
-			}
-		}
-		if won {
-			fmt.Println("row", i)
-			return true
-		}
+func newBoardstate(b board, lines []Line) boardstate {
+	bs := boardstate{
+		marked: make([][]bool, len(b)),
+		lines:  lines,
 	}
+	for i := range b {
+		bs.marked[i] = make([]bool, len(b[i]))
+	}
+	return bs
+}
 
-	// check cols
-	for j := 0; j < 5; j++ {
+func newBoardstates(boards []board, lines []Line) []boardstate {
+	out := make([]boardstate, len(boards))
+	for p, b := range boards {
+		out[p] = newBoardstate(b, lines)
+	}
+	return out
+}
+
+func (bs boardstate) clone() boardstate {
+	out := boardstate{
+		marked: make([][]bool, len(bs.marked)),
+		lines:  bs.lines,
+	}
+	for i := range bs.marked {
+		out.marked[i] = append([]bool(nil), bs.marked[i]...)
+	}
+	return out
+}
+
+// winningLine returns the name of the first of the winning lines that is fully
+// marked.
+func (bs boardstate) winningLine() (string, bool) {
+	for _, l := range bs.lines {
 		won := true
-		for i := 0; i < 5; i++ {
-			if !bs[i][j] {
+		for _, c := range l.Cells {
+			if !bs.marked[c[0]][c[1]] {
 				won = false
 				break
 			}
 		}
 		if won {
-			fmt.Println("col", j)
-			return true
+			return l.Name, true
 		}
 	}
-	return false
+	return "", false
 }
 
-func part1() {
-	numbers, boards := getData()
-	//now track values in each board, see if it wins
-	boardstates := make([]boardstate, len(boards))
-	for _, v := range numbers {
-		fmt.Println(v)
-		for p, b := range boards {
-			i, j := b.contains(v)
-			if i != -1 {
-				boardstates[p][i][j] = true
-				if boardstates[p].won() {
-					fmt.Println("winner!", b)
-					lastNum, _ := strconv.Atoi(v)
-					fmt.Println(b.score(boardstates[p], lastNum))
-					return
-				}
-			}
-		}
+// play reads the input and plays the whole game once. Both parts are questions
+// about how it went.
+func play() *Game {
+	numbers, boards, lines := getData()
+	g := NewGame(boards, lines)
+	g.Log = events
+	g.Play(numbers)
+	return g
+}
+
+func part1(g *Game) {
+	f, ok := g.First()
+	if !ok {
+		fmt.Println("no board won")
+		return
 	}
+	fmt.Println("winner!", g.boards[f.Board])
+	fmt.Println(f.Score)
 }
 
-func part2() {
-	numbers, boards := getData()
-	//now track values in each board, see if it wins
-	boardstates := make([]boardstate, len(boards))
-	didWin := make([]bool, len(boards))
-	for _, v := range numbers {
-		fmt.Println(v)
-		for p, b := range boards {
-			if didWin[p] {
-				continue
-			}
-			i, j := b.contains(v)
-			if i != -1 {
-				boardstates[p][i][j] = true
-				if boardstates[p].won() {
-					fmt.Println("winner!", b)
-					lastNum, _ := strconv.Atoi(v)
-					fmt.Println(b.score(boardstates[p], lastNum))
-					didWin[p] = true
-				}
-			}
-		}
+func part2(g *Game) {
+	f, ok := g.Last()
+	if !ok {
+		fmt.Println("no board won")
+		return
 	}
+	fmt.Println("winner!", g.boards[f.Board])
+	fmt.Println(f.Score)
 }
 
-func getData() ([]string, []board) {
+// getData reads the numbers to draw and the boards, and builds the winning lines
+// from -win. The first board sets how many rows and columns they all have.
+func getData() ([]string, []board, []Line) {
 	f, err := os.Open("./day4/input.txt")
 	if err != nil {
 		panic(err)
 	}
-	scanner := bufio.NewScanner(f)
-
-	scanner.Split(bufio.ScanLines)
+	defer f.Close()
+	scanner := input.Lines(f, "./day4/input.txt")
 
 	//read calls
-	scanner.Scan()
-	numberLine := scanner.Text()
-	numbers := strings.Split(numberLine, ",")
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			panic(err)
+		}
+		panic("./day4/input.txt: no numbers to draw")
+	}
+	numbers := strings.Split(strings.TrimSpace(scanner.Text()), ",")
+	for i, v := range numbers {
+		if _, err := strconv.Atoi(v); err != nil {
+			panic(fmt.Errorf("line 1: number %d to draw, %q, is not a whole number", i+1, v))
+		}
+	}
 	var boards []board
-	//read boards
-	for scanner.Scan() {
-		//skip blank line
-		var curBoard board
-		//lines 1- 5
-		for i := 0; i < 5; i++ {
-			scanner.Scan()
-			vals := strings.Fields(scanner.Text())
-			curBoard = append(curBoard, vals)
+	var curBoard board
+	start, line := 0, 1
+	rows, cols := 0, 0
+	// endBoard checks the board that has just been read against the first one
+	endBoard := func() {
+		if curBoard == nil {
+			return
+		}
+		if len(boards) == 0 {
+			rows, cols = len(curBoard), len(curBoard[0])
+		} else if len(curBoard) != rows {
+			panic(fmt.Errorf("line %d: board %d has %d rows, but board 0 has %d", start, len(boards), len(curBoard), rows))
 		}
 		boards = append(boards, curBoard)
+		curBoard = nil
+	}
+	//read boards, separated by blank lines
+	for scanner.Scan() {
+		line++
+		vals := strings.Fields(scanner.Text())
+		if len(vals) == 0 {
+			endBoard()
+			continue
+		}
+		if curBoard == nil {
+			start = line
+		}
+		want := cols
+		if len(boards) == 0 {
+			if curBoard != nil {
+				want = len(curBoard[0])
+			} else {
+				want = len(vals)
+			}
+		}
+		if len(vals) != want {
+			panic(fmt.Errorf("line %d: board %d row %d has %d numbers, expected %d", line, len(boards), len(curBoard), len(vals), want))
+		}
+		for _, v := range vals {
+			if _, err := strconv.Atoi(v); err != nil {
+				panic(fmt.Errorf("line %d: %q is not a whole number", line, v))
+			}
+		}
+		curBoard = append(curBoard, vals)
+	}
+	line++
+	endBoard()
+	if err := scanner.Err(); err != nil {
+		panic(err)
+	}
+	if len(boards) == 0 {
+		panic("./day4/input.txt: no boards")
+	}
+	lines, err := winLines(*winFlag, rows, cols)
+	if err != nil {
+		panic(fmt.Errorf("-win: %w", err))
 	}
-	f.Close()
-	return numbers, boards
+	return numbers, boards, lines
 }
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"advent-2021/eventlog"
+)
+
+// cell is one place a number appears.
+type cell struct {
+	board, row, col int
+}
+
+// Finish is a board winning: on which draw (counting from 1), with which
+// number, along which winning line and for what score.
+type Finish struct {
+	Board  int
+	Draw   int
+	Number string
+	Line   string
+	Score  int
+}
+
+// Game plays every board at once without searching them. An index takes each
+// number straight to the cells it is in, and each board keeps a count of the
+// cells marked on each of its winning lines and the sum of its unmarked
+// numbers, so marking a cell, checking for a win and scoring it take time in
+// proportion to the lines through that cell rather than the size of the board.
+type Game struct {
+	boards []board
+	lines  []Line
+	index  map[string][]cell
+	// linesAt[i][j] lists the lines through row i, column j
+	linesAt [][][]int
+	states  []boardstate
+	// marked[p][l] is how many cells of line l are marked on board p
+	marked   [][]int
+	unmarked []int
+	finished []bool
+	draws    int
+
+	// Finishes lists the boards that have won, in the order they won.
+	Finishes []Finish
+	// Log, if set, records each draw, mark and win.
+	Log *eventlog.Log
+}
+
+func NewGame(boards []board, lines []Line) *Game {
+	g := &Game{
+		boards:   boards,
+		lines:    lines,
+		index:    map[string][]cell{},
+		states:   newBoardstates(boards, lines),
+		marked:   make([][]int, len(boards)),
+		unmarked: make([]int, len(boards)),
+		finished: make([]bool, len(boards)),
+	}
+	for p, b := range boards {
+		g.marked[p] = make([]int, len(lines))
+		for i := range b {
+			for j, v := range b[i] {
+				g.index[v] = append(g.index[v], cell{p, i, j})
+				n, _ := strconv.Atoi(v)
+				g.unmarked[p] += n
+			}
+		}
+	}
+	if len(boards) > 0 {
+		g.linesAt = make([][][]int, len(boards[0]))
+		for i := range g.linesAt {
+			g.linesAt[i] = make([][]int, len(boards[0][i]))
+		}
+	}
+	for l, line := range lines {
+		for _, c := range line.Cells {
+			g.linesAt[c[0]][c[1]] = append(g.linesAt[c[0]][c[1]], l)
+		}
+	}
+	return g
+}
+
+// Draw marks v on every board that hasn't already won, and returns the boards
+// that won because of it.
+func (g *Game) Draw(v string) []Finish {
+	g.draws++
+	n, _ := strconv.Atoi(v)
+	if g.Log.Enabled() {
+		g.Log.Emit("draw", eventlog.Fields{"num": v}, "drew %s", v)
+	}
+	var out []Finish
+	for _, c := range g.index[v] {
+		bs := g.states[c.board]
+		if g.finished[c.board] || bs.marked[c.row][c.col] {
+			continue
+		}
+		bs.marked[c.row][c.col] = true
+		if g.Log.Enabled() {
+			g.Log.Emit("mark", eventlog.Fields{"board": c.board, "row": c.row, "col": c.col}, "board %d marked (%d,%d)", c.board, c.row, c.col)
+		}
+		g.unmarked[c.board] -= n
+		for _, l := range g.linesAt[c.row][c.col] {
+			g.marked[c.board][l]++
+			if g.marked[c.board][l] == len(g.lines[l].Cells) && !g.finished[c.board] {
+				g.finished[c.board] = true
+				f := Finish{
+					Board:  c.board,
+					Draw:   g.draws,
+					Number: v,
+					Line:   g.lines[l].Name,
+					Score:  g.unmarked[c.board] * n,
+				}
+				g.Finishes = append(g.Finishes, f)
+				out = append(out, f)
+				if g.Log.Enabled() {
+					g.Log.Emit("win", eventlog.Fields{"board": f.Board, "score": f.Score}, "board %d won on %s, score %d", f.Board, v, f.Score)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// Play draws every number in turn, stopping early once every board has won.
+func (g *Game) Play(numbers []string) {
+	for _, v := range numbers {
+		if len(g.Finishes) == len(g.boards) {
+			return
+		}
+		g.Draw(v)
+	}
+}
+
+// Finished returns how board p won, if it has.
+func (g *Game) Finished(p int) (Finish, bool) {
+	if !g.finished[p] {
+		return Finish{}, false
+	}
+	for _, f := range g.Finishes {
+		if f.Board == p {
+			return f, true
+		}
+	}
+	return Finish{}, false
+}
+
+// First returns the board that won first.
+func (g *Game) First() (Finish, bool) {
+	if len(g.Finishes) == 0 {
+		return Finish{}, false
+	}
+	return g.Finishes[0], true
+}
+
+// Last returns the board that won last. Boards that never won don't count.
+func (g *Game) Last() (Finish, bool) {
+	if len(g.Finishes) == 0 {
+		return Finish{}, false
+	}
+	return g.Finishes[len(g.Finishes)-1], true
+}
+
+// Print writes the finish order as a table.
+func (g *Game) Print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "place\tboard\tdraw\tnumber\tline\tscore")
+	for place, f := range g.Finishes {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%s\t%s\t%d\n", place+1, f.Board, f.Draw, f.Number, f.Line, f.Score)
+	}
+	tw.Flush()
+	if left := len(g.boards) - len(g.Finishes); left > 0 {
+		fmt.Fprintf(w, "%d boards never won\n", left)
+	}
+}
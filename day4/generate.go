@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// GenSpec describes a game to generate: Boards boards of Rows by Cols, each
+// holding distinct numbers from Min to Max, and every number from Min to Max
+// drawn once in a random order.
+type GenSpec struct {
+	Boards int
+	Rows   int
+	Cols   int
+	Min    int
+	Max    int
+	// FirstBoard, if not -1, must be the only board to win on draw FirstDraw,
+	// counting from 1, and no board may win before it.
+	FirstBoard int
+	FirstDraw  int
+	// Distinct stops two boards winning on the same draw.
+	Distinct bool
+}
+
+// Generate builds a game that meets spec, trying up to attempts times. Every
+// game is played through Game before it is returned, so the constraints are
+// checked by the same logic that solves the puzzle rather than trusted to the
+// way the boards were built.
+func Generate(spec GenSpec, lines []Line, r *rand.Rand, attempts int) ([]string, []board, error) {
+	cells := spec.Rows * spec.Cols
+	if spec.Boards < 1 || spec.Rows < 1 || spec.Cols < 1 {
+		return nil, nil, fmt.Errorf("can't generate %d boards of %dx%d", spec.Boards, spec.Rows, spec.Cols)
+	}
+	if spec.Max-spec.Min+1 < cells {
+		return nil, nil, fmt.Errorf("%d to %d is too few numbers to fill a %dx%d board", spec.Min, spec.Max, spec.Rows, spec.Cols)
+	}
+	if spec.FirstBoard >= spec.Boards {
+		return nil, nil, fmt.Errorf("there is no board %d among %d", spec.FirstBoard, spec.Boards)
+	}
+	var err error
+	for i := 0; i < attempts; i++ {
+		numbers := r.Perm(spec.Max - spec.Min + 1)
+		for j := range numbers {
+			numbers[j] += spec.Min
+		}
+		var boards []board
+		if spec.FirstBoard == -1 && !spec.Distinct {
+			boards = randomBoards(spec, numbers, r)
+		} else if boards, err = plannedBoards(spec, numbers, lines, r); err != nil {
+			return nil, nil, err
+		}
+		draws := make([]string, len(numbers))
+		for j, n := range numbers {
+			draws[j] = strconv.Itoa(n)
+		}
+		g := NewGame(boards, lines)
+		g.Play(draws)
+		if err = spec.check(g); err == nil {
+			return draws, boards, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("no game met the constraints in %d attempts, the last because %v", attempts, err)
+}
+
+func randomBoards(spec GenSpec, numbers []int, r *rand.Rand) []board {
+	boards := make([]board, spec.Boards)
+	for p := range boards {
+		perm := r.Perm(len(numbers))
+		boards[p] = fill(spec, func(k int) int { return numbers[perm[k]] })
+	}
+	return boards
+}
+
+// fill makes a board from the numbers nth returns for each cell in turn.
+func fill(spec GenSpec, nth func(k int) int) board {
+	b := make(board, spec.Rows)
+	for i := range b {
+		b[i] = make([]string, spec.Cols)
+		for j := range b[i] {
+			b[i][j] = strconv.Itoa(nth(i*spec.Cols + j))
+		}
+	}
+	return b
+}
+
+// plannedBoards picks the draw each board is to win on and builds the board
+// around it: a random winning line is filled with numbers drawn up to that
+// draw, ending with the number drawn on it, and every other cell with numbers
+// drawn after it, so nothing can win sooner.
+func plannedBoards(spec GenSpec, numbers []int, lines []Line, r *rand.Rand) ([]board, error) {
+	targets, err := spec.targets(lines, len(numbers), r)
+	if err != nil {
+		return nil, err
+	}
+	boards := make([]board, spec.Boards)
+	for p, d := range targets {
+		l := lines[r.Intn(len(lines))]
+		for len(l.Cells) > d {
+			l = lines[r.Intn(len(lines))]
+		}
+		before := r.Perm(d - 1)
+		after := r.Perm(len(numbers) - d)
+		inLine := map[[2]int]int{}
+		for k, c := range l.Cells {
+			inLine[c] = k
+		}
+		used, rest := 0, 0
+		boards[p] = fill(spec, func(k int) int {
+			c := [2]int{k / spec.Cols, k % spec.Cols}
+			if _, ok := inLine[c]; ok {
+				used++
+				if used == len(l.Cells) {
+					return numbers[d-1]
+				}
+				return numbers[before[used-1]]
+			}
+			rest++
+			return numbers[d+after[rest-1]]
+		})
+	}
+	return boards, nil
+}
+
+// targets picks the draw each board is to win on.
+func (spec GenSpec) targets(lines []Line, draws int, r *rand.Rand) ([]int, error) {
+	shortest := len(lines[0].Cells)
+	for _, l := range lines {
+		if len(l.Cells) < shortest {
+			shortest = len(l.Cells)
+		}
+	}
+	// a board needs enough numbers drawn before its win to fill the line, and
+	// enough after it to fill the rest of the board
+	lo, hi := shortest, draws-(spec.Rows*spec.Cols-shortest)
+	if spec.FirstBoard != -1 {
+		if spec.FirstDraw < lo || spec.FirstDraw > hi {
+			return nil, fmt.Errorf("board %d can't win on draw %d, only on draws %d to %d", spec.FirstBoard, spec.FirstDraw, lo, hi)
+		}
+		lo = spec.FirstDraw + 1
+	}
+	others := spec.Boards
+	if spec.FirstBoard != -1 {
+		others--
+	}
+	var picks []int
+	if spec.Distinct {
+		if hi-lo+1 < others {
+			return nil, fmt.Errorf("%d boards can't all win on different draws between %d and %d", others, lo, hi)
+		}
+		for _, k := range r.Perm(hi - lo + 1)[:others] {
+			picks = append(picks, lo+k)
+		}
+	} else {
+		if others > 0 && lo > hi {
+			return nil, fmt.Errorf("no draws left for the other boards to win on after draw %d", spec.FirstDraw)
+		}
+		for k := 0; k < others; k++ {
+			picks = append(picks, lo+r.Intn(hi-lo+1))
+		}
+	}
+	if spec.FirstBoard == -1 {
+		return picks, nil
+	}
+	out := append([]int(nil), picks[:spec.FirstBoard]...)
+	out = append(out, spec.FirstDraw)
+	return append(out, picks[spec.FirstBoard:]...), nil
+}
+
+// check reports how the played game g breaks spec, if it does.
+func (spec GenSpec) check(g *Game) error {
+	if spec.FirstBoard != -1 {
+		first, ok := g.First()
+		switch {
+		case !ok:
+			return fmt.Errorf("no board won")
+		case first.Board != spec.FirstBoard || first.Draw != spec.FirstDraw:
+			return fmt.Errorf("board %d won first, on draw %d", first.Board, first.Draw)
+		case len(g.Finishes) > 1 && g.Finishes[1].Draw == first.Draw:
+			return fmt.Errorf("board %d won on draw %d too", g.Finishes[1].Board, first.Draw)
+		}
+	}
+	if spec.Distinct {
+		for k := 1; k < len(g.Finishes); k++ {
+			if g.Finishes[k].Draw == g.Finishes[k-1].Draw {
+				return fmt.Errorf("boards %d and %d both won on draw %d", g.Finishes[k-1].Board, g.Finishes[k].Board, g.Finishes[k].Draw)
+			}
+		}
+	}
+	return nil
+}
+
+// writeGame writes a game in the puzzle's input format.
+func writeGame(w io.Writer, numbers []string, boards []board) error {
+	if _, err := fmt.Fprintln(w, strings.Join(numbers, ",")); err != nil {
+		return err
+	}
+	for _, b := range boards {
+		fmt.Fprintln(w)
+		for _, row := range b {
+			cells := make([]string, len(row))
+			for j, v := range row {
+				cells[j] = fmt.Sprintf("%2s", v)
+			}
+			if _, err := fmt.Fprintln(w, strings.Join(cells, " ")); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
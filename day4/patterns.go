@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var winFlag = flag.String("win", "rows,cols", "comma separated ways to win: rows, cols, diagonals, corners, full, or mask:ROW/ROW/... with a 1 for each cell that must be marked")
+
+// Line is a set of cells that wins the board once all of them are marked.
+type Line struct {
+	Name  string
+	Cells [][2]int
+}
+
+// winLines builds the lines described by spec for boards of rows by cols.
+func winLines(spec string, rows, cols int) ([]Line, error) {
+	var out []Line
+	for _, kind := range strings.Split(spec, ",") {
+		switch {
+		case kind == "rows":
+			for i := 0; i < rows; i++ {
+				l := Line{Name: "row " + strconv.Itoa(i)}
+				for j := 0; j < cols; j++ {
+					l.Cells = append(l.Cells, [2]int{i, j})
+				}
+				out = append(out, l)
+			}
+		case kind == "cols":
+			for j := 0; j < cols; j++ {
+				l := Line{Name: "col " + strconv.Itoa(j)}
+				for i := 0; i < rows; i++ {
+					l.Cells = append(l.Cells, [2]int{i, j})
+				}
+				out = append(out, l)
+			}
+		case kind == "diagonals":
+			if rows != cols {
+				return nil, fmt.Errorf("diagonals need square boards, these are %dx%d", rows, cols)
+			}
+			down, up := Line{Name: "diagonal"}, Line{Name: "antidiagonal"}
+			for i := 0; i < rows; i++ {
+				down.Cells = append(down.Cells, [2]int{i, i})
+				up.Cells = append(up.Cells, [2]int{rows - 1 - i, i})
+			}
+			out = append(out, down, up)
+		case kind == "corners":
+			out = append(out, Line{Name: "corners", Cells: [][2]int{
+				{0, 0}, {0, cols - 1}, {rows - 1, 0}, {rows - 1, cols - 1},
+			}})
+		case kind == "full":
+			l := Line{Name: "full card"}
+			for i := 0; i < rows; i++ {
+				for j := 0; j < cols; j++ {
+					l.Cells = append(l.Cells, [2]int{i, j})
+				}
+			}
+			out = append(out, l)
+		case strings.HasPrefix(kind, "mask:"):
+			l, err := maskLine(strings.TrimPrefix(kind, "mask:"), rows, cols)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, l)
+		default:
+			return nil, fmt.Errorf("unknown way to win %q", kind)
+		}
+	}
+	return out, nil
+}
+
+// maskLine reads a mask such as 10001/01010/00100/01010/10001, one group of
+// digits per row.
+func maskLine(mask string, rows, cols int) (Line, error) {
+	l := Line{Name: "mask " + mask}
+	maskRows := strings.Split(mask, "/")
+	if len(maskRows) != rows {
+		return l, fmt.Errorf("mask %q has %d rows, the boards have %d", mask, len(maskRows), rows)
+	}
+	for i, r := range maskRows {
+		if len(r) != cols {
+			return l, fmt.Errorf("mask %q row %d has %d cells, the boards have %d columns", mask, i, len(r), cols)
+		}
+		for j, c := range r {
+			switch c {
+			case '1':
+				l.Cells = append(l.Cells, [2]int{i, j})
+			case '0':
+			default:
+				return l, fmt.Errorf("mask %q can only contain 0, 1 and /", mask)
+			}
+		}
+	}
+	if len(l.Cells) == 0 {
+		return l, fmt.Errorf("mask %q has no cells to mark", mask)
+	}
+	return l, nil
+}
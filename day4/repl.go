@@ -0,0 +1,176 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"advent-2021/repl"
+)
+
+// bingoSim plays the game one draw at a time for the repl.
+type bingoSim struct {
+	numbers []string
+	boards  []board
+	bingoState
+}
+
+type bingoState struct {
+	states []boardstate
+	next   int
+	// winners holds the boards that have won, in the order they won
+	winners []bingoWin
+}
+
+type bingoWin struct {
+	board int
+	line  string
+	draw  int
+	score int
+}
+
+func newBingoSim(numbers []string, boards []board, lines []Line) *bingoSim {
+	return &bingoSim{
+		numbers: numbers,
+		boards:  boards,
+		bingoState: bingoState{
+			states: newBoardstates(boards, lines),
+		},
+	}
+}
+
+func (b *bingoSim) hasWon(p int) bool {
+	for _, w := range b.winners {
+		if w.board == p {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *bingoSim) lastNum() int {
+	if b.next == 0 {
+		return 0
+	}
+	n, _ := strconv.Atoi(b.numbers[b.next-1])
+	return n
+}
+
+func (b *bingoSim) Step() error {
+	if b.next >= len(b.numbers) {
+		return repl.ErrDone
+	}
+	v := b.numbers[b.next]
+	b.next++
+	for p, bd := range b.boards {
+		if b.hasWon(p) {
+			continue
+		}
+		i, j := bd.contains(v)
+		if i != -1 {
+			b.states[p].marked[i][j] = true
+			b.checkWin(p)
+		}
+	}
+	return nil
+}
+
+func (b *bingoSim) Show(w io.Writer) {
+	if b.next > 0 {
+		fmt.Fprintf(w, "draw %d of %d: %s\n", b.next, len(b.numbers), b.numbers[b.next-1])
+	} else {
+		fmt.Fprintf(w, "no numbers drawn yet, %d to go\n", len(b.numbers))
+	}
+	for p, bd := range b.boards {
+		fmt.Fprintf(w, "board %d\n", p)
+		for i := range bd {
+			for j, v := range bd[i] {
+				if b.states[p].marked[i][j] {
+					fmt.Fprintf(w, "[%2s]", v)
+				} else {
+					fmt.Fprintf(w, " %2s ", v)
+				}
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	for place, win := range b.winners {
+		fmt.Fprintf(w, "winner %d: board %d, %s, on draw %d, score %d\n", place+1, win.board, win.line, win.draw, win.score)
+	}
+}
+
+// checkWin records board p as a winner if it has just won. Wins are reported by
+// Show rather than printed here, so that everything goes to the repl's writer.
+func (b *bingoSim) checkWin(p int) {
+	if b.hasWon(p) {
+		return
+	}
+	line, won := b.states[p].winningLine()
+	if !won {
+		return
+	}
+	b.winners = append(b.winners, bingoWin{
+		board: p,
+		line:  line,
+		draw:  b.next,
+		score: b.boards[p].score(b.states[p], b.lastNum()),
+	})
+}
+
+func (b *bingoSim) Usage() string {
+	return "<board> <row> <col> on|off"
+}
+
+func (b *bingoSim) Set(args []string) error {
+	if len(args) != 4 {
+		return errors.New("wrong number of arguments")
+	}
+	var pos [3]int
+	for i := range pos {
+		n, err := strconv.Atoi(args[i])
+		if err != nil {
+			return err
+		}
+		pos[i] = n
+	}
+	p, i, j := pos[0], pos[1], pos[2]
+	if p < 0 || p >= len(b.boards) || i < 0 || i >= len(b.boards[p]) || j < 0 || j >= len(b.boards[p][i]) {
+		return fmt.Errorf("no cell %d,%d on board %d", i, j, p)
+	}
+	switch args[3] {
+	case "on":
+		b.states[p].marked[i][j] = true
+		b.checkWin(p)
+	case "off":
+		b.states[p].marked[i][j] = false
+	default:
+		return fmt.Errorf("expected on or off, got %q", args[3])
+	}
+	return nil
+}
+
+func cloneStates(states []boardstate) []boardstate {
+	out := make([]boardstate, len(states))
+	for p, bs := range states {
+		out[p] = bs.clone()
+	}
+	return out
+}
+
+func (b *bingoSim) Snapshot() interface{} {
+	return bingoState{
+		states:  cloneStates(b.states),
+		next:    b.next,
+		winners: append([]bingoWin(nil), b.winners...),
+	}
+}
+
+func (b *bingoSim) Restore(state interface{}) {
+	s := state.(bingoState)
+	b.bingoState = bingoState{
+		states:  cloneStates(s.states),
+		next:    s.next,
+		winners: append([]bingoWin(nil), s.winners...),
+	}
+}
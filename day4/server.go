@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"advent-2021/eventlog"
+)
+
+/*
+BingoServer runs a game for players connected over TCP. The protocol is plain
+lines of text, so nc works as a client as well as the client command:
+
+	client: join NAME
+	server: board K            the player is dealt board K,
+	server: row N N N ...      one line per row,
+	server: ready              then waits for the others
+	server: start              everyone has joined
+	server: draw I N           the Ith number drawn, counting from 1, is N
+	client: bingo!             claim a win
+	server: bingo NAME board K LINE on draw I, score S
+	                           sent to everyone when a claim is good
+	server: no bingo           sent to the claimant when it isn't
+	server: over               no more numbers, or every player has won
+
+Claims are checked against the server's own marks, never the client's, and the
+score is the one the board had when it first won, however late the claim.
+*/
+type BingoServer struct {
+	numbers []string
+	boards  []board
+	lines   []Line
+	// Players is how many players must join before the draws start, and
+	// Interval is the time between draws.
+	Players  int
+	Interval time.Duration
+
+	mu      sync.Mutex
+	game    *Game
+	players []*player
+	winners int
+	full    chan struct{}
+	done    bool
+}
+
+type player struct {
+	name  string
+	board int
+	conn  net.Conn
+	w     *bufio.Writer
+	won   bool
+}
+
+func NewBingoServer(numbers []string, boards []board, lines []Line, players int, interval time.Duration) (*BingoServer, error) {
+	if players < 1 || players > len(boards) {
+		return nil, fmt.Errorf("can't deal %d players from %d boards", players, len(boards))
+	}
+	return &BingoServer{
+		numbers:  numbers,
+		boards:   boards[:players],
+		lines:    lines,
+		Players:  players,
+		Interval: interval,
+		game:     NewGame(boards[:players], lines),
+		full:     make(chan struct{}),
+	}, nil
+}
+
+// Serve accepts players on l until the game is full, plays it and then closes
+// l.
+func (s *BingoServer) Serve(l net.Listener) error {
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+	<-s.full
+
+	s.mu.Lock()
+	s.broadcast("start")
+	s.mu.Unlock()
+	for i, v := range s.numbers {
+		time.Sleep(s.Interval)
+		s.mu.Lock()
+		if s.winners == len(s.players) {
+			s.mu.Unlock()
+			break
+		}
+		s.game.Draw(v)
+		events.Emit("draw", eventlog.Fields{"num": v}, "drew %s", v)
+		s.broadcast(fmt.Sprintf("draw %d %s", i+1, v))
+		s.mu.Unlock()
+	}
+	// give the last draw's claims a chance to arrive
+	time.Sleep(s.Interval)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.broadcast("over")
+	s.done = true
+	for _, p := range s.players {
+		p.conn.Close()
+	}
+	return nil
+}
+
+// broadcast sends msg to every player. s.mu must be held.
+func (s *BingoServer) broadcast(msg string) {
+	for _, p := range s.players {
+		s.send(p, msg)
+	}
+}
+
+// send writes msg to p. A player whose connection fails just stops hearing
+// about the game. s.mu must be held.
+func (s *BingoServer) send(p *player, msg string) {
+	p.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	fmt.Fprintln(p.w, msg)
+	p.w.Flush()
+}
+
+func (s *BingoServer) handle(conn net.Conn) {
+	r := bufio.NewScanner(conn)
+	if !r.Scan() {
+		conn.Close()
+		return
+	}
+	fields := strings.Fields(r.Text())
+	if len(fields) != 2 || fields[0] != "join" {
+		fmt.Fprintln(conn, "expected join NAME")
+		conn.Close()
+		return
+	}
+	p, ok := s.join(fields[1], conn)
+	if !ok {
+		return
+	}
+	for r.Scan() {
+		switch strings.TrimSpace(r.Text()) {
+		case "bingo!":
+			s.claim(p)
+		case "":
+		default:
+			s.mu.Lock()
+			s.send(p, "expected bingo!")
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *BingoServer) join(name string, conn net.Conn) (*player, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.players) == s.Players {
+		fmt.Fprintln(conn, "game full")
+		conn.Close()
+		return nil, false
+	}
+	p := &player{
+		name:  name,
+		board: len(s.players),
+		conn:  conn,
+		w:     bufio.NewWriter(conn),
+	}
+	s.players = append(s.players, p)
+	events.Emit("join", eventlog.Fields{"player": name, "board": p.board}, "%s joined with board %d", name, p.board)
+	s.send(p, "board "+strconv.Itoa(p.board))
+	for _, row := range s.boards[p.board] {
+		s.send(p, "row "+strings.Join(row, " "))
+	}
+	s.send(p, "ready")
+	if len(s.players) == s.Players {
+		close(s.full)
+	}
+	return p, true
+}
+
+func (s *BingoServer) claim(p *player) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return
+	}
+	if p.won {
+		s.send(p, "already won")
+		return
+	}
+	f, ok := s.game.Finished(p.board)
+	if !ok {
+		events.Emit("claim", eventlog.Fields{"player": p.name, "good": false}, "%s claimed bingo too soon", p.name)
+		s.send(p, "no bingo")
+		return
+	}
+	p.won = true
+	s.winners++
+	events.Emit("win", eventlog.Fields{"player": p.name, "board": p.board, "score": f.Score}, "%s won with board %d, score %d", p.name, p.board, f.Score)
+	s.broadcast(fmt.Sprintf("bingo %s board %d %s on draw %d, score %d", p.name, p.board, f.Line, f.Draw, f.Score))
+}
+
+// Client plays a board for name against the server at the other end of conn:
+// it marks each number drawn and claims bingo as soon as its board wins. It
+// copies everything the server says to out.
+func Client(conn io.ReadWriter, name string, out io.Writer) error {
+	fmt.Fprintf(conn, "join %s\n", name)
+	r := bufio.NewScanner(conn)
+	var b board
+	var bs boardstate
+	claimed := false
+	for r.Scan() {
+		line := r.Text()
+		fmt.Fprintln(out, line)
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "row":
+			b = append(b, fields[1:])
+		case "ready":
+			if len(b) == 0 {
+				return fmt.Errorf("no board dealt")
+			}
+			lines, err := winLines(*winFlag, len(b), len(b[0]))
+			if err != nil {
+				return err
+			}
+			bs = newBoardstate(b, lines)
+		case "draw":
+			if len(fields) != 3 || bs.marked == nil {
+				continue
+			}
+			if i, j := b.contains(fields[2]); i != -1 {
+				bs.marked[i][j] = true
+			}
+			if _, won := bs.winningLine(); won && !claimed {
+				claimed = true
+				fmt.Fprintln(conn, "bingo!")
+			}
+		case "over", "game":
+			return nil
+		}
+	}
+	return r.Err()
+}
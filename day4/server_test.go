@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// the example game from the puzzle
+var testNumbers = strings.Split("7,4,9,5,11,17,23,2,0,14,21,24,10,16,13,6,15,25,12,22,18,20,8,19,3,26,1", ",")
+
+var testBoards = []board{
+	parseTestBoard("22 13 17 11 0/8 2 23 4 24/21 9 14 16 7/6 10 3 18 5/1 12 20 15 19"),
+	parseTestBoard("3 15 0 2 22/9 18 13 17 5/19 8 7 25 23/20 11 10 24 4/14 21 16 12 6"),
+	parseTestBoard("14 21 17 24 4/10 16 15 9 19/18 8 23 26 20/22 11 13 6 5/2 0 12 3 7"),
+}
+
+func parseTestBoard(s string) board {
+	var b board
+	for _, row := range strings.Split(s, "/") {
+		b = append(b, strings.Fields(row))
+	}
+	return b
+}
+
+// TestServeClients plays a game between scripted clients in the same process,
+// as the client command does over the network. Run it with -race: the clients
+// work out their own wins concurrently.
+func TestServeClients(t *testing.T) {
+	names := []string{"alice", "bob", "carol"}
+	l, served := serveTestGame(t, len(names), time.Millisecond)
+
+	outs := make([]bytes.Buffer, len(names))
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			conn, err := net.Dial("tcp", l.Addr().String())
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer conn.Close()
+			errs[i] = Client(conn, name, &outs[i])
+		}(i, name)
+	}
+	wg.Wait()
+	if err := <-served; err != nil {
+		t.Fatal(err)
+	}
+
+	// boards are dealt in the order players join, so check each client
+	// against the board it was given
+	want := map[string]string{
+		"0": "row 2 on draw 14, score 2192",
+		"1": "col 2 on draw 15, score 1924",
+		"2": "row 0 on draw 12, score 4512",
+	}
+	for i, name := range names {
+		if errs[i] != nil {
+			t.Fatalf("%s: %v", name, errs[i])
+		}
+		out := outs[i].String()
+		var dealt string
+		if _, err := fmt.Sscanf(out, "board %s\n", &dealt); err != nil {
+			t.Fatalf("%s wasn't dealt a board:\n%s", name, out)
+		}
+		win := fmt.Sprintf("bingo %s board %s %s\n", name, dealt, want[dealt])
+		if !strings.Contains(out, win) {
+			t.Errorf("%s didn't hear %q:\n%s", name, win, out)
+		}
+		if !strings.HasSuffix(out, "over\n") {
+			t.Errorf("%s's game didn't end with over:\n%s", name, out)
+		}
+	}
+}
+
+// TestServeFalseClaim has a player claim bingo before any number is drawn,
+// which the server must turn down.
+func TestServeFalseClaim(t *testing.T) {
+	l, served := serveTestGame(t, 1, 5*time.Millisecond)
+	conn, r := joinTestGame(t, l, "mallory")
+	defer conn.Close()
+	readUntil(t, r, "start")
+	fmt.Fprintln(conn, "bingo!")
+	readUntil(t, r, "no bingo")
+	if err := <-served; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestServeGameFull has one player more than the game takes try to join.
+func TestServeGameFull(t *testing.T) {
+	l, served := serveTestGame(t, 1, 20*time.Millisecond)
+	alice, r := joinTestGame(t, l, "alice")
+	defer alice.Close()
+	readUntil(t, r, "ready")
+
+	bob, r := joinTestGame(t, l, "bob")
+	defer bob.Close()
+	if !r.Scan() || r.Text() != "game full" {
+		t.Errorf("bob heard %q, want game full", r.Text())
+	}
+	if err := <-served; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// serveTestGame starts a game of the puzzle's example for players on a local
+// port, and returns the listener and a channel that Serve's error is sent on.
+func serveTestGame(t *testing.T, players int, interval time.Duration) (net.Listener, chan error) {
+	t.Helper()
+	lines, err := winLines("rows,cols", 5, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := NewBingoServer(testNumbers, testBoards, lines, players, interval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(l)
+	}()
+	return l, served
+}
+
+// joinTestGame connects to the server on l as name, without the client's help,
+// and returns the connection and a scanner over what the server says.
+func joinTestGame(t *testing.T, l net.Listener, name string) (net.Conn, *bufio.Scanner) {
+	t.Helper()
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(conn, "join %s\n", name)
+	return conn, bufio.NewScanner(conn)
+}
+
+// readUntil reads lines from r until one is want, failing if the server hangs
+// up first.
+func readUntil(t *testing.T, r *bufio.Scanner, want string) {
+	t.Helper()
+	var heard []string
+	for r.Scan() {
+		if r.Text() == want {
+			return
+		}
+		heard = append(heard, r.Text())
+	}
+	t.Fatalf("never heard %q, only:\n%s", want, strings.Join(heard, "\n"))
+}
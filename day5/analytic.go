@@ -0,0 +1,283 @@
+package main
+
+import (
+	"sort"
+)
+
+// Every segment lies on a line in one of four directions. Along a line, a
+// point is given by t: x for horizontal and both diagonal directions, y for
+// vertical. key picks the line: y for horizontal, x for vertical, x-y for
+// down diagonals and x+y for up diagonals.
+const (
+	horizontal = iota
+	vertical
+	downDiagonal
+	upDiagonal
+)
+
+type onLine struct {
+	dir, key int
+	lo, hi   int
+}
+
+func (s Segment) onLine() onLine {
+	l := onLine{lo: s.X1, hi: s.X2}
+	switch {
+	case s.Horizontal():
+		l.dir, l.key = horizontal, s.Y1
+	case s.Vertical():
+		l.dir, l.key, l.lo, l.hi = vertical, s.X1, s.Y1, s.Y2
+	case (s.X2-s.X1 > 0) == (s.Y2-s.Y1 > 0):
+		l.dir, l.key = downDiagonal, s.X1-s.Y1
+	default:
+		l.dir, l.key = upDiagonal, s.X1+s.Y1
+	}
+	if l.lo > l.hi {
+		l.lo, l.hi = l.hi, l.lo
+	}
+	return l
+}
+
+// lineKey returns the key of the line in direction dir through x,y, and where
+// x,y is along it.
+func lineKey(dir, x, y int) (key, t int) {
+	switch dir {
+	case horizontal:
+		return y, x
+	case vertical:
+		return x, y
+	case downDiagonal:
+		return x - y, x
+	}
+	return x + y, x
+}
+
+// analytic counts overlaps without visiting the points of the segments, so its
+// cost depends on the number of segments and of crossings rather than on how
+// long the segments are.
+//
+// A point covered twice is either covered by two segments on the same line or
+// is where two segments on different lines cross. The first kind are found by
+// sweeping along each line for stretches covered more than once, which gives
+// disjoint ranges per line. The second kind are found a pair of directions at
+// a time by a sweep that reports every crossing. A crossing can also be inside
+// overlapping ranges, on up to one line per direction, so each crossing adds
+// one point if it is in no range and takes back the extra counts if it is in
+// more than one.
+type analytic struct {
+	segs []Segment
+}
+
+func (a *analytic) Add(s Segment) {
+	a.segs = append(a.segs, s)
+}
+
+func (a *analytic) Overlaps() int {
+	var byDir [4][]onLine
+	for _, s := range a.segs {
+		l := s.onLine()
+		byDir[l.dir] = append(byDir[l.dir], l)
+	}
+
+	// ranges[dir][key] holds the stretches of that line covered twice or
+	// more, sorted and not touching
+	var ranges [4]map[int][][2]int
+	count := 0
+	for dir, lines := range byDir {
+		ranges[dir] = repeated(lines)
+		for _, rs := range ranges[dir] {
+			for _, r := range rs {
+				count += r[1] - r[0] + 1
+			}
+		}
+	}
+
+	crossings := map[[2]int]bool{}
+	for d1 := 0; d1 < 4; d1++ {
+		for d2 := d1 + 1; d2 < 4; d2++ {
+			cross(byDir[d1], byDir[d2], func(x, y int) {
+				crossings[[2]int{x, y}] = true
+			})
+		}
+	}
+	for p := range crossings {
+		in := 0
+		for dir := range ranges {
+			key, t := lineKey(dir, p[0], p[1])
+			if contains(ranges[dir][key], t) {
+				in++
+			}
+		}
+		if in == 0 {
+			count++
+		} else {
+			count -= in - 1
+		}
+	}
+	return count
+}
+
+// repeated finds the stretches of each line covered by more than one of lines,
+// which all run in the same direction.
+func repeated(lines []onLine) map[int][][2]int {
+	byKey := map[int][]onLine{}
+	for _, l := range lines {
+		byKey[l.key] = append(byKey[l.key], l)
+	}
+	out := map[int][][2]int{}
+	type edge struct {
+		t, delta int
+	}
+	for key, ls := range byKey {
+		if len(ls) < 2 {
+			continue
+		}
+		edges := make([]edge, 0, 2*len(ls))
+		for _, l := range ls {
+			edges = append(edges, edge{l.lo, 1}, edge{l.hi + 1, -1})
+		}
+		sort.Slice(edges, func(i, j int) bool {
+			return edges[i].t < edges[j].t
+		})
+		var rs [][2]int
+		depth := 0
+		for i, e := range edges {
+			depth += e.delta
+			if depth < 2 || i+1 == len(edges) || edges[i+1].t == e.t {
+				continue
+			}
+			r := [2]int{e.t, edges[i+1].t - 1}
+			if n := len(rs); n > 0 && rs[n-1][1]+1 == r[0] {
+				rs[n-1][1] = r[1]
+			} else {
+				rs = append(rs, r)
+			}
+		}
+		if len(rs) > 0 {
+			out[key] = rs
+		}
+	}
+	return out
+}
+
+func contains(rs [][2]int, t int) bool {
+	i := sort.Search(len(rs), func(i int) bool {
+		return rs[i][1] >= t
+	})
+	return i < len(rs) && rs[i][0] <= t
+}
+
+// cross calls f with every point where a line in as crosses one in bs, which
+// run in different directions. It changes coordinates so that as are all
+// horizontal and bs all vertical, and sweeps from left to right.
+func cross(as, bs []onLine, f func(x, y int)) {
+	if len(as) == 0 || len(bs) == 0 {
+		return
+	}
+	// to and from convert between x,y and u,v, where as run along u and bs
+	// along v
+	var to func(x, y int) (int, int)
+	var from func(u, v int) (int, int, bool)
+	switch [2]int{as[0].dir, bs[0].dir} {
+	case [2]int{horizontal, vertical}:
+		to = func(x, y int) (int, int) { return x, y }
+		from = func(u, v int) (int, int, bool) { return u, v, true }
+	case [2]int{horizontal, downDiagonal}:
+		to = func(x, y int) (int, int) { return x - y, y }
+		from = func(u, v int) (int, int, bool) { return u + v, v, true }
+	case [2]int{horizontal, upDiagonal}:
+		to = func(x, y int) (int, int) { return x + y, y }
+		from = func(u, v int) (int, int, bool) { return u - v, v, true }
+	case [2]int{vertical, downDiagonal}:
+		to = func(x, y int) (int, int) { return x - y, x }
+		from = func(u, v int) (int, int, bool) { return v, v - u, true }
+	case [2]int{vertical, upDiagonal}:
+		to = func(x, y int) (int, int) { return x + y, x }
+		from = func(u, v int) (int, int, bool) { return v, u - v, true }
+	default:
+		// down diagonals against up diagonals
+		to = func(x, y int) (int, int) { return x + y, x - y }
+		from = func(u, v int) (int, int, bool) {
+			// lines of different parity cross between points
+			if (u+v)%2 != 0 {
+				return 0, 0, false
+			}
+			return (u + v) / 2, (u - v) / 2, true
+		}
+	}
+
+	// events are sorted by u; at the same u, segments of as start before bs
+	// are checked against them, and finish after
+	const (
+		start = iota
+		check
+		finish
+	)
+	type event struct {
+		u, kind int
+		v       int
+		lo, hi  int
+	}
+	var events []event
+	for _, l := range as {
+		x1, y1 := l.point(l.lo)
+		x2, y2 := l.point(l.hi)
+		u1, v := to(x1, y1)
+		u2, _ := to(x2, y2)
+		if u1 > u2 {
+			u1, u2 = u2, u1
+		}
+		events = append(events, event{u: u1, kind: start, v: v}, event{u: u2, kind: finish, v: v})
+	}
+	for _, l := range bs {
+		x1, y1 := l.point(l.lo)
+		x2, y2 := l.point(l.hi)
+		u, v1 := to(x1, y1)
+		_, v2 := to(x2, y2)
+		if v1 > v2 {
+			v1, v2 = v2, v1
+		}
+		events = append(events, event{u: u, kind: check, lo: v1, hi: v2})
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].u != events[j].u {
+			return events[i].u < events[j].u
+		}
+		return events[i].kind < events[j].kind
+	})
+
+	// active holds the v of every segment of as that the sweep is inside,
+	// sorted
+	var active []int
+	for _, e := range events {
+		switch e.kind {
+		case start:
+			i := sort.SearchInts(active, e.v)
+			active = append(active, 0)
+			copy(active[i+1:], active[i:])
+			active[i] = e.v
+		case finish:
+			i := sort.SearchInts(active, e.v)
+			active = append(active[:i], active[i+1:]...)
+		case check:
+			for i := sort.SearchInts(active, e.lo); i < len(active) && active[i] <= e.hi; i++ {
+				if x, y, ok := from(e.u, active[i]); ok {
+					f(x, y)
+				}
+			}
+		}
+	}
+}
+
+// point returns the x,y of the point t along l.
+func (l onLine) point(t int) (int, int) {
+	switch l.dir {
+	case horizontal:
+		return t, l.key
+	case vertical:
+		return l.key, t
+	case downDiagonal:
+		return t, t - l.key
+	}
+	return t, l.key - t
+}
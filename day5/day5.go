@@ -1,16 +1,28 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
+
+	"advent-2021/difftest"
+	"advent-2021/input"
 )
 
 func main() {
-	process(&Part1{})
-	process(&Part2{})
+	flag.Parse()
+	if flag.Arg(0) == "diff" {
+		var props []difftest.Property
+		for _, m := range []string{"dense", "points", "rows", "analytic"} {
+			props = append(props, ventsProperty(m, false), ventsProperty(m, true))
+		}
+		difftest.Main(flag.Args()[1:], append(props, analyticProperty)...)
+		return
+	}
+	process(&Vents{Map: *mapFlag})
+	process(&Vents{Diagonals: true, Map: *mapFlag})
 }
 
 /*
@@ -248,12 +260,13 @@ func process(p Processor) {
 		panic(err)
 	}
 	defer f.Close()
-	scanner := bufio.NewScanner(f)
-
-	scanner.Split(bufio.ScanLines)
+	scanner := input.Lines(f, "./day5/input.txt")
 
 	for scanner.Scan() {
 		p.Process(scanner.Text())
 	}
+	if err := scanner.Err(); err != nil {
+		panic(err)
+	}
 	fmt.Println(p.Result())
 }
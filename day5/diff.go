@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+
+	"advent-2021/difftest"
+)
+
+// ventsProperty checks Vents, forced to use map m, against the grid in Part2,
+// or in Part1 if diagonals is false.
+func ventsProperty(m string, diagonals bool) difftest.Property {
+	name := "day5 Part2 vs Vents "
+	if !diagonals {
+		name = "day5 Part1 vs Vents "
+	}
+	return difftest.Property{
+		Name:     name + m,
+		Generate: generateVents(0),
+		Shrink:   shrinkVents,
+		Reference: func(v interface{}) interface{} {
+			if diagonals {
+				p := &Part2{}
+				for _, line := range v.([]string) {
+					p.Process(line)
+				}
+				return p.Result()
+			}
+			// Part1 prints each diagonal it skips, so it isn't given them;
+			// Vents still is, and has to skip them itself
+			p := &Part1{}
+			for _, line := range v.([]string) {
+				if s, _ := parseSegment(line); s.Horizontal() || s.Vertical() {
+					p.Process(line)
+				}
+			}
+			return p.Result()
+		},
+		Optimized: func(v interface{}) interface{} {
+			p := &Vents{Diagonals: diagonals, Map: m}
+			for _, line := range v.([]string) {
+				p.Process(line)
+			}
+			return p.Result()
+		},
+	}
+}
+
+// analyticProperty checks the analytic count against counting points one by
+// one. Part2 can't cope with negative coordinates, so this compares with the
+// point map instead, on segments around the origin.
+var analyticProperty = difftest.Property{
+	Name:     "day5 Vents points vs Vents analytic",
+	Generate: generateVents(-5),
+	Shrink:   shrinkVents,
+	Reference: func(v interface{}) interface{} {
+		p := &Vents{Diagonals: true, Map: "points"}
+		for _, line := range v.([]string) {
+			p.Process(line)
+		}
+		return p.Result()
+	},
+	Optimized: func(v interface{}) interface{} {
+		p := &Vents{Diagonals: true, Map: "analytic"}
+		for _, line := range v.([]string) {
+			p.Process(line)
+		}
+		return p.Result()
+	},
+}
+
+func shrinkVents(v interface{}) []interface{} {
+	var out []interface{}
+	for _, smaller := range difftest.ShrinkStrings(v.([]string)) {
+		out = append(out, smaller)
+	}
+	return out
+}
+
+// generateVents returns a generator of a few horizontal, vertical and diagonal
+// lines in a small grid, so they often overlap. The grid starts at min,min.
+func generateVents(min int) func(r *rand.Rand) interface{} {
+	return func(r *rand.Rand) interface{} {
+		return ventLines(r, min)
+	}
+}
+
+func ventLines(r *rand.Rand, min int) []string {
+	var lines []string
+	for i := r.Intn(12) + 1; i > 0; i-- {
+		x1, y1 := r.Intn(10), r.Intn(10)
+		x2, y2 := x1, y1
+		n := r.Intn(10)
+		switch r.Intn(3) {
+		case 0:
+			x2 = r.Intn(10)
+		case 1:
+			y2 = r.Intn(10)
+		default:
+			dx, dy := 1, 1
+			if r.Intn(2) == 0 {
+				dx = -1
+			}
+			if r.Intn(2) == 0 {
+				dy = -1
+			}
+			for ; n > 0 && x2+dx >= 0 && y2+dy >= 0; n-- {
+				x2, y2 = x2+dx, y2+dy
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%d,%d -> %d,%d", x1+min, y1+min, x2+min, y2+min))
+	}
+	return lines
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var mapFlag = flag.String("map", "auto", "how to count the overlapping vents: auto, dense, points, rows or analytic")
+
+// Segment is a line of vents from (X1,Y1) to (X2,Y2), both ends included.
+type Segment struct {
+	X1, Y1, X2, Y2 int
+}
+
+// parseSegment reads a line like 781,721 -> 781,611. Lines must be horizontal,
+// vertical or at 45 degrees.
+func parseSegment(s string) (Segment, error) {
+	var seg Segment
+	parts := strings.Split(s, "->")
+	if len(parts) != 2 {
+		return seg, fmt.Errorf("%q should look like x1,y1 -> x2,y2", s)
+	}
+	var vals [4]int
+	for i, part := range parts {
+		xy := strings.Split(part, ",")
+		if len(xy) != 2 {
+			return seg, fmt.Errorf("%q should look like x1,y1 -> x2,y2", s)
+		}
+		for j, v := range xy {
+			n, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return seg, fmt.Errorf("%q: %q is not a whole number", s, strings.TrimSpace(v))
+			}
+			vals[i*2+j] = n
+		}
+	}
+	seg = Segment{vals[0], vals[1], vals[2], vals[3]}
+	if !seg.Horizontal() && !seg.Vertical() && abs(seg.X2-seg.X1) != abs(seg.Y2-seg.Y1) {
+		return seg, fmt.Errorf("%q is not horizontal, vertical or diagonal", s)
+	}
+	return seg, nil
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func sign(v int) int {
+	switch {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	}
+	return 0
+}
+
+func (s Segment) Horizontal() bool { return s.Y1 == s.Y2 }
+func (s Segment) Vertical() bool   { return s.X1 == s.X2 }
+
+// Len is the number of points on the segment.
+func (s Segment) Len() int {
+	if n := abs(s.X2 - s.X1); n > abs(s.Y2-s.Y1) {
+		return n + 1
+	}
+	return abs(s.Y2-s.Y1) + 1
+}
+
+// each calls f with every point on the segment.
+func (s Segment) each(f func(x, y int)) {
+	dx, dy := sign(s.X2-s.X1), sign(s.Y2-s.Y1)
+	for i, x, y := 0, s.X1, s.Y1; i < s.Len(); i, x, y = i+1, x+dx, y+dy {
+		f(x, y)
+	}
+}
+
+// VentMap counts how many segments cover each point.
+type VentMap interface {
+	Add(s Segment)
+	// Overlaps is the number of points covered by two or more segments.
+	Overlaps() int
+}
+
+// Vents reads segments and counts the points where they overlap, like Part1
+// and Part2 but without a grid from 0,0 to the largest coordinate. It holds on
+// to the segments until the end, and only then picks a map to suit them:
+//
+//	dense   a grid over just the area the segments span, when it is small
+//	        and mostly covered
+//	rows    for each row, the ranges of x its segments cover, when most of
+//	        the points are on horizontal segments
+//	points  a count for each covered point
+//	analytic
+//	        no map at all, just the segments, when they cover so many
+//	        points that visiting each one would be slow
+//
+// so memory grows with the points covered rather than with the size of the
+// coordinates, and negative coordinates are no different from positive ones.
+type Vents struct {
+	// Diagonals counts diagonal segments too, as Part2 does.
+	Diagonals bool
+	// Map is dense, points, rows or analytic to force a map, or auto or empty
+	// to pick.
+	Map string
+	// Chosen is the map that was used.
+	Chosen string
+
+	line int
+	segs []Segment
+}
+
+// denseLimit is the largest area that will be stored as a grid.
+const denseLimit = 1 << 24
+
+// analyticLimit is the most points the segments can cover before overlaps are
+// worked out from the segments alone.
+const analyticLimit = 1 << 24
+
+func (v *Vents) Process(s string) {
+	v.line++
+	if strings.TrimSpace(s) == "" {
+		return
+	}
+	seg, err := parseSegment(s)
+	if err != nil {
+		panic(fmt.Errorf("line %d: %w", v.line, err))
+	}
+	if !v.Diagonals && !seg.Horizontal() && !seg.Vertical() {
+		return
+	}
+	v.segs = append(v.segs, seg)
+}
+
+func (v *Vents) Result() int {
+	v.Chosen = v.Map
+	if v.Chosen == "" || v.Chosen == "auto" {
+		v.Chosen = chooseMap(v.segs)
+	}
+	var m VentMap
+	switch v.Chosen {
+	case "dense":
+		m = newDenseMap(v.segs)
+	case "points":
+		m = pointMap{}
+	case "rows":
+		m = rowMap{}
+	case "analytic":
+		m = &analytic{}
+	default:
+		panic(fmt.Errorf("unknown vent map %q, expected auto, dense, points, rows or analytic", v.Chosen))
+	}
+	for _, s := range v.segs {
+		m.Add(s)
+	}
+	return m.Overlaps()
+}
+
+func chooseMap(segs []Segment) string {
+	if len(segs) == 0 {
+		return "points"
+	}
+	minX, minY, maxX, maxY := bounds(segs)
+	covered, horizontal := 0, 0
+	for _, s := range segs {
+		covered += s.Len()
+		if s.Horizontal() {
+			horizontal += s.Len()
+		}
+	}
+	// compare in floating point, as the area of huge coordinates overflows
+	area := float64(maxX-minX+1) * float64(maxY-minY+1)
+	switch {
+	case covered > analyticLimit:
+		return "analytic"
+	case area <= denseLimit && area <= 4*float64(covered):
+		return "dense"
+	case 2*horizontal > covered:
+		return "rows"
+	}
+	return "points"
+}
+
+func bounds(segs []Segment) (minX, minY, maxX, maxY int) {
+	minX, minY, maxX, maxY = segs[0].X1, segs[0].Y1, segs[0].X1, segs[0].Y1
+	for _, s := range segs {
+		for _, p := range [][2]int{{s.X1, s.Y1}, {s.X2, s.Y2}} {
+			if p[0] < minX {
+				minX = p[0]
+			}
+			if p[0] > maxX {
+				maxX = p[0]
+			}
+			if p[1] < minY {
+				minY = p[1]
+			}
+			if p[1] > maxY {
+				maxY = p[1]
+			}
+		}
+	}
+	return minX, minY, maxX, maxY
+}
+
+// denseMap is a grid covering only the bounding box of the segments.
+type denseMap struct {
+	minX, minY int
+	width      int
+	counts     []int
+}
+
+func newDenseMap(segs []Segment) *denseMap {
+	if len(segs) == 0 {
+		return &denseMap{}
+	}
+	minX, minY, maxX, maxY := bounds(segs)
+	width := maxX - minX + 1
+	return &denseMap{
+		minX:   minX,
+		minY:   minY,
+		width:  width,
+		counts: make([]int, width*(maxY-minY+1)),
+	}
+}
+
+func (m *denseMap) Add(s Segment) {
+	s.each(func(x, y int) {
+		m.counts[(y-m.minY)*m.width+x-m.minX]++
+	})
+}
+
+func (m *denseMap) Overlaps() int {
+	count := 0
+	for _, c := range m.counts {
+		if c > 1 {
+			count++
+		}
+	}
+	return count
+}
+
+type pointMap map[[2]int]int
+
+func (m pointMap) Add(s Segment) {
+	s.each(func(x, y int) {
+		m[[2]int{x, y}]++
+	})
+}
+
+func (m pointMap) Overlaps() int {
+	count := 0
+	for _, c := range m {
+		if c > 1 {
+			count++
+		}
+	}
+	return count
+}
+
+// rowMap keeps, for each row, the ranges of x covered by segments. A
+// horizontal segment is one range however long it is; other segments add a
+// range of one point to each row they cross.
+type rowMap map[int][][2]int
+
+func (m rowMap) Add(s Segment) {
+	if s.Horizontal() {
+		lo, hi := s.X1, s.X2
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		m[s.Y1] = append(m[s.Y1], [2]int{lo, hi})
+		return
+	}
+	s.each(func(x, y int) {
+		m[y] = append(m[y], [2]int{x, x})
+	})
+}
+
+// Overlaps sweeps along each row, keeping track of how many ranges cover the
+// current point.
+func (m rowMap) Overlaps() int {
+	count := 0
+	type edge struct {
+		x, delta int
+	}
+	for _, ranges := range m {
+		edges := make([]edge, 0, 2*len(ranges))
+		for _, r := range ranges {
+			edges = append(edges, edge{r[0], 1}, edge{r[1] + 1, -1})
+		}
+		sort.Slice(edges, func(i, j int) bool {
+			return edges[i].x < edges[j].x
+		})
+		depth := 0
+		for i, e := range edges {
+			depth += e.delta
+			if depth > 1 && i+1 < len(edges) {
+				count += edges[i+1].x - e.x
+			}
+		}
+	}
+	return count
+}
@@ -1,16 +1,32 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
+
+	"advent-2021/difftest"
+	"advent-2021/input"
+	"advent-2021/repl"
 )
 
 func main() {
+	flag.Parse()
+	if flag.Arg(0) == "diff" {
+		difftest.Main(flag.Args()[1:], fishProperty)
+		return
+	}
+	if flag.Arg(0) == "repl" {
+		if err := repl.Run("day6", &fishSim{fish: getInitial()}, os.Stdin, os.Stdout); err != nil {
+			panic(err)
+		}
+		return
+	}
 	part1()
 	part2()
 }
@@ -63,32 +79,63 @@ func part1() {
 	//in = []byte{3, 4, 3, 1, 2}
 	for i := 0; i < 80; i++ {
 		fmt.Println("day", i, ":", len(in))
-		temp := make([]byte, 0, len(in))
-		for _, v := range in {
-			switch v {
-			case 0:
-				temp = append(temp, 8, 6)
-			default:
-				temp = append(temp, v-1)
-			}
-		}
-		in = temp
+		in = tick(in)
 	}
 	fmt.Println(len(in))
 }
 
+// simulate steps every fish through each day and returns how many there are at
+// the end. It is the reference implementation that countFish is checked against.
+func simulate(in []byte, days int) int {
+	for i := 0; i < days; i++ {
+		in = tick(in)
+	}
+	return len(in)
+}
+
+// tick returns the fish after one more day.
+func tick(in []byte) []byte {
+	temp := make([]byte, 0, len(in))
+	for _, v := range in {
+		switch v {
+		case 0:
+			temp = append(temp, 8, 6)
+		default:
+			temp = append(temp, v-1)
+		}
+	}
+	return temp
+}
+
 func part2() {
 	in := getInitial()
 	//in = []byte{3, 4, 3, 1, 2}
-	lookup := make([]int, 7)
+	fmt.Println(countFish(in, 256, os.Stdout))
+}
+
+// countFish works out how many fish there are after days without simulating them,
+// by counting the descendants of a single fish for each starting timer value.
+// If timings isn't nil, the count and time taken for each timer value are
+// written to it as they finish.
+//
+// The puzzle's input only has timers up to 6, but 7 and 8 are valid too, as a
+// new fish starts at 8, and the diff command generates them, so the lookup
+// covers all nine.
+func countFish(in []byte, days int, timings io.Writer) int {
+	lookup := make([]int, 9)
+	var mu sync.Mutex
 	var wg sync.WaitGroup
-	wg.Add(7)
-	for i := 0; i <= 6; i++ {
+	wg.Add(len(lookup))
+	for i := range lookup {
 		go func(i int) {
 			start := time.Now()
-			curSum := sumIt(i)
+			curSum := sumIt(i, days)
 			lookup[i] = curSum
-			fmt.Println(i, curSum, time.Now().Sub(start))
+			if timings != nil {
+				mu.Lock()
+				fmt.Fprintln(timings, i, curSum, time.Now().Sub(start))
+				mu.Unlock()
+			}
 			wg.Done()
 		}(i)
 	}
@@ -97,12 +144,12 @@ func part2() {
 	for _, v := range in {
 		total += lookup[v]
 	}
-	fmt.Println(total)
+	return total
 }
 
-func sumIt(pos int) int {
+func sumIt(pos int, days int) int {
 	//fmt.Println("in sumIt starting at ", pos)
-	made := int(math.Ceil((256 - float64(pos)) / 7))
+	made := int(math.Ceil((float64(days) - float64(pos)) / 7))
 	if made < 0 {
 		return 0
 	}
@@ -110,21 +157,34 @@ func sumIt(pos int) int {
 	total := made
 	for i := 0; i <= made; i++ {
 		p := pos + 9 + 7*i
-		if p < 256 {
-			total += sumIt(p)
+		if p < days {
+			total += sumIt(p, days)
 		}
 	}
 	return total
 }
 
 func getInitial() []byte {
-	contents, _ := os.ReadFile("./day6/input.txt")
-	initial := strings.Split(string(contents), ",")
-	//fmt.Println(initial)
-	in := make([]byte, len(initial), 1_000)
-	for i := 0; i < len(initial); i++ {
-		b, _ := strconv.Atoi(strings.TrimSpace(initial[i]))
-		in[i] = byte(b)
+	f, err := os.Open("./day6/input.txt")
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	scanner := input.Commas(f, "./day6/input.txt")
+
+	in := make([]byte, 0, 1_000)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		b, err := strconv.Atoi(scanner.Text())
+		if err != nil {
+			panic(err)
+		}
+		in = append(in, byte(b))
+	}
+	if err := scanner.Err(); err != nil {
+		panic(err)
 	}
 	return in
 }
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"math/rand"
+
+	"advent-2021/difftest"
+)
+
+type fishInput struct {
+	Fish []int
+	Days int
+}
+
+func (in fishInput) timers() []byte {
+	out := make([]byte, len(in.Fish))
+	for i, v := range in.Fish {
+		out[i] = byte(v)
+	}
+	return out
+}
+
+// fishProperty checks countFish against simulate. Days are kept small so that
+// the simulation stays cheap.
+var fishProperty = difftest.Property{
+	Name: "day6 simulate vs countFish",
+	Generate: func(r *rand.Rand) interface{} {
+		in := fishInput{Days: r.Intn(60)}
+		for i := r.Intn(10) + 1; i > 0; i-- {
+			in.Fish = append(in.Fish, r.Intn(9))
+		}
+		return in
+	},
+	Shrink: func(v interface{}) []interface{} {
+		in := v.(fishInput)
+		var out []interface{}
+		if in.Days > 0 {
+			out = append(out, fishInput{Fish: in.Fish, Days: in.Days / 2}, fishInput{Fish: in.Fish, Days: in.Days - 1})
+		}
+		for _, fish := range difftest.ShrinkInts(in.Fish) {
+			out = append(out, fishInput{Fish: fish, Days: in.Days})
+		}
+		return out
+	},
+	Reference: func(v interface{}) interface{} {
+		in := v.(fishInput)
+		return simulate(in.timers(), in.Days)
+	},
+	Optimized: func(v interface{}) interface{} {
+		in := v.(fishInput)
+		return countFish(in.timers(), in.Days, nil)
+	},
+}
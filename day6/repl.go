@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// fishSim advances the school one day at a time for the repl.
+type fishSim struct {
+	fish []byte
+	day  int
+}
+
+type fishState struct {
+	fish []byte
+	day  int
+}
+
+func (f *fishSim) Step() error {
+	f.fish = tick(f.fish)
+	f.day++
+	return nil
+}
+
+func (f *fishSim) Show(w io.Writer) {
+	var counts [9]int
+	for _, v := range f.fish {
+		counts[v]++
+	}
+	fmt.Fprintf(w, "after %d days: %d fish\n", f.day, len(f.fish))
+	for timer, count := range counts {
+		fmt.Fprintf(w, "  timer %d: %d\n", timer, count)
+	}
+	if len(f.fish) <= 50 {
+		for i, v := range f.fish {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprint(w, v)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func (f *fishSim) Usage() string {
+	return "<fish> <timer>"
+}
+
+func (f *fishSim) Set(args []string) error {
+	if len(args) != 2 {
+		return errors.New("wrong number of arguments")
+	}
+	i, err := strconv.Atoi(args[0])
+	if err != nil {
+		return err
+	}
+	timer, err := strconv.Atoi(args[1])
+	if err != nil {
+		return err
+	}
+	if i < 0 || i >= len(f.fish) {
+		return fmt.Errorf("there is no fish %d", i)
+	}
+	if timer < 0 || timer > 8 {
+		return fmt.Errorf("timer %d is not between 0 and 8", timer)
+	}
+	f.fish[i] = byte(timer)
+	return nil
+}
+
+func (f *fishSim) Snapshot() interface{} {
+	return fishState{
+		fish: append([]byte(nil), f.fish...),
+		day:  f.day,
+	}
+}
+
+func (f *fishSim) Restore(state interface{}) {
+	s := state.(fishState)
+	f.fish = append([]byte(nil), s.fish...)
+	f.day = s.day
+}
@@ -1,14 +1,23 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"math"
 	"os"
+	"sort"
 	"strconv"
-	"strings"
+
+	"advent-2021/difftest"
+	"advent-2021/input"
 )
 
 func main() {
+	flag.Parse()
+	if flag.Arg(0) == "diff" {
+		difftest.Main(flag.Args()[1:], linearFuelProperty, triangularFuelProperty)
+		return
+	}
 	part1()
 	part2()
 }
@@ -40,17 +49,24 @@ Determine the horizontal position that the crabs can align to using the least fu
 func part1() {
 	vals := getInitial()
 	//vals = []int{16, 1, 2, 0, 4, 2, 7, 1, 2, 14}
+	minPos, minTotal := cheapest(vals, totalDistance)
+	fmt.Println(minPos, minTotal)
+}
+
+// cheapest tries every position from 0 to the furthest crab and returns the one
+// with the lowest cost, along with that cost.
+func cheapest(vals []int, cost func([]int, int) int) (int, int) {
 	minTotal := math.MaxInt
 	minPos := 0
 	max := max(vals)
 	for i := 0; i <= max; i++ {
-		total := totalDistance(vals, i)
+		total := cost(vals, i)
 		if total < minTotal {
 			minTotal = total
 			minPos = i
 		}
 	}
-	fmt.Println(minPos, minTotal)
+	return minPos, minTotal
 }
 
 /*
@@ -75,16 +91,7 @@ Determine the horizontal position that the crabs can align to using the least fu
 func part2() {
 	vals := getInitial()
 	//vals = []int{16, 1, 2, 0, 4, 2, 7, 1, 2, 14}
-	minTotal := math.MaxInt
-	minPos := 0
-	max := max(vals)
-	for i := 0; i <= max; i++ {
-		total := totalDistance2(vals, i)
-		if total < minTotal {
-			minTotal = total
-			minPos = i
-		}
-	}
+	minPos, minTotal := cheapest(vals, totalDistance2)
 	fmt.Println(minPos, minTotal)
 }
 
@@ -116,14 +123,53 @@ func totalDistance2(vals []int, pos int) int {
 	return total
 }
 
+// medianPosition finds the cheapest position for totalDistance directly. The sum
+// of distances is smallest at the median, so there is no need to try them all.
+func medianPosition(vals []int) (int, int) {
+	sorted := append([]int(nil), vals...)
+	sort.Ints(sorted)
+	pos := sorted[(len(sorted)-1)/2]
+	return pos, totalDistance(vals, pos)
+}
+
+// meanPosition finds the cheapest position for totalDistance2 directly. The best
+// position is always within half a step of the mean, so only the whole numbers
+// either side of it need to be tried.
+func meanPosition(vals []int) (int, int) {
+	sum := 0
+	for _, v := range vals {
+		sum += v
+	}
+	lower := int(math.Floor(float64(sum) / float64(len(vals))))
+	lowerTotal := totalDistance2(vals, lower)
+	upperTotal := totalDistance2(vals, lower+1)
+	if upperTotal < lowerTotal {
+		return lower + 1, upperTotal
+	}
+	return lower, lowerTotal
+}
+
 func getInitial() []int {
-	contents, _ := os.ReadFile("./day7/input.txt")
-	initial := strings.Split(string(contents), ",")
-	//fmt.Println(initial)
-	in := make([]int, len(initial), 1_000)
-	for i := 0; i < len(initial); i++ {
-		b, _ := strconv.Atoi(strings.TrimSpace(initial[i]))
-		in[i] = b
+	f, err := os.Open("./day7/input.txt")
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	scanner := input.Commas(f, "./day7/input.txt")
+
+	in := make([]int, 0, 1_000)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		b, err := strconv.Atoi(scanner.Text())
+		if err != nil {
+			panic(err)
+		}
+		in = append(in, b)
+	}
+	if err := scanner.Err(); err != nil {
+		panic(err)
 	}
 	return in
 }
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"math/rand"
+
+	"advent-2021/difftest"
+)
+
+// Only the fuel is compared: when several positions tie, the brute force search
+// and the direct methods are free to pick different ones.
+
+func generateCrabs(r *rand.Rand) interface{} {
+	var vals []int
+	for i := r.Intn(20) + 1; i > 0; i-- {
+		vals = append(vals, r.Intn(50))
+	}
+	return vals
+}
+
+func shrinkCrabs(v interface{}) []interface{} {
+	var out []interface{}
+	for _, vals := range difftest.ShrinkInts(v.([]int)) {
+		if len(vals) > 0 {
+			out = append(out, vals)
+		}
+	}
+	return out
+}
+
+var linearFuelProperty = difftest.Property{
+	Name:     "day7 cheapest vs medianPosition",
+	Generate: generateCrabs,
+	Shrink:   shrinkCrabs,
+	Reference: func(v interface{}) interface{} {
+		_, total := cheapest(v.([]int), totalDistance)
+		return total
+	},
+	Optimized: func(v interface{}) interface{} {
+		_, total := medianPosition(v.([]int))
+		return total
+	},
+}
+
+var triangularFuelProperty = difftest.Property{
+	Name:     "day7 cheapest vs meanPosition",
+	Generate: generateCrabs,
+	Shrink:   shrinkCrabs,
+	Reference: func(v interface{}) interface{} {
+		_, total := cheapest(v.([]int), totalDistance2)
+		return total
+	},
+	Optimized: func(v interface{}) interface{} {
+		_, total := meanPosition(v.([]int))
+		return total
+	},
+}
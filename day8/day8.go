@@ -1,14 +1,17 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
 	"os"
 	"sort"
 	"strings"
+
+	"advent-2021/input"
 )
 
 func main() {
+	flag.Parse()
 	process(&Part1{})
 	process(&Part2{})
 }
@@ -322,12 +325,13 @@ func process(p Processor) {
 		panic(err)
 	}
 	defer f.Close()
-	scanner := bufio.NewScanner(f)
-
-	scanner.Split(bufio.ScanLines)
+	scanner := input.Lines(f, "./day8/input.txt")
 
 	for scanner.Scan() {
 		p.Process(scanner.Text())
 	}
+	if err := scanner.Err(); err != nil {
+		panic(err)
+	}
 	fmt.Println(p.Result())
 }
@@ -1,13 +1,16 @@
 package main
 
 import (
-	"bytes"
+	"flag"
 	"fmt"
 	"os"
 	"sort"
+
+	"advent-2021/input"
 )
 
 func main() {
+	flag.Parse()
 	part1()
 	part2()
 }
@@ -184,11 +187,20 @@ func printGrid(colors [][]int) {
 }
 
 func getInitial() [][]byte {
-	contents, _ := os.ReadFile("./day9/input.txt")
-	grid := bytes.Split(contents, []byte{'\n'})
-	if len(grid[len(grid)-1]) == 0 {
-		grid = grid[:len(grid)-1]
+	f, err := os.Open("./day9/input.txt")
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	scanner := input.Lines(f, "./day9/input.txt")
+
+	var grid [][]byte
+	for scanner.Scan() {
+		// the scanner reuses its buffer, so keep a copy of each row
+		grid = append(grid, append([]byte(nil), scanner.Bytes()...))
+	}
+	if err := scanner.Err(); err != nil {
+		panic(err)
 	}
-	// last line is blank
 	return grid
 }
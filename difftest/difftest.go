@@ -0,0 +1,183 @@
+// Package difftest runs two implementations of the same puzzle computation
+// against randomly generated inputs and reports the first input on which they
+// disagree, shrunk down to a minimal counterexample.
+//
+// Inputs and results are passed around as interface{} and compared with
+// reflect.DeepEqual, so each day wraps its own input type in a Property.
+package difftest
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"reflect"
+	"time"
+)
+
+// Property describes a reference (naive) and an optimized implementation of the
+// same computation, along with how to generate and shrink inputs for them.
+type Property struct {
+	Name string
+	// Generate builds a random input.
+	Generate func(r *rand.Rand) interface{}
+	// Shrink returns candidate inputs that are strictly smaller than in.
+	// It may be nil, in which case counterexamples are reported as generated.
+	Shrink    func(in interface{}) []interface{}
+	Reference func(in interface{}) interface{}
+	Optimized func(in interface{}) interface{}
+}
+
+// Outcome is what one implementation did with an input: either it returned a
+// value or it panicked.
+type Outcome struct {
+	Value interface{}
+	Panic interface{}
+}
+
+func (o Outcome) String() string {
+	if o.Panic != nil {
+		return fmt.Sprintf("panic: %v", o.Panic)
+	}
+	return fmt.Sprintf("%v", o.Value)
+}
+
+// agrees reports whether two outcomes are the same. Two panics count as
+// agreement, since both implementations rejected the input.
+func (o Outcome) agrees(other Outcome) bool {
+	if o.Panic != nil || other.Panic != nil {
+		return o.Panic != nil && other.Panic != nil
+	}
+	return reflect.DeepEqual(o.Value, other.Value)
+}
+
+func run(f func(in interface{}) interface{}, in interface{}) (out Outcome) {
+	defer func() {
+		if r := recover(); r != nil {
+			out.Panic = r
+		}
+	}()
+	out.Value = f(in)
+	return out
+}
+
+// Counterexample is an input on which the two implementations disagree.
+type Counterexample struct {
+	Name     string
+	Seed     int64
+	Trial    int
+	Original interface{}
+	Input    interface{}
+	Shrinks  int
+
+	Reference Outcome
+	Optimized Outcome
+}
+
+func (c *Counterexample) String() string {
+	return fmt.Sprintf("%s: disagreement on trial %d (seed %d), shrunk %d times\n  input:     %+v\n  reference: %v\n  optimized: %v",
+		c.Name, c.Trial, c.Seed, c.Shrinks, c.Input, c.Reference, c.Optimized)
+}
+
+// Check runs trials random inputs through both implementations of p. It returns
+// nil if they always agree, or the first disagreement after shrinking it.
+func Check(p Property, seed int64, trials int) *Counterexample {
+	r := rand.New(rand.NewSource(seed))
+	for i := 0; i < trials; i++ {
+		in := p.Generate(r)
+		ref, opt := run(p.Reference, in), run(p.Optimized, in)
+		if ref.agrees(opt) {
+			continue
+		}
+		c := &Counterexample{
+			Name:      p.Name,
+			Seed:      seed,
+			Trial:     i,
+			Original:  in,
+			Input:     in,
+			Reference: ref,
+			Optimized: opt,
+		}
+		shrink(p, c)
+		return c
+	}
+	return nil
+}
+
+// shrink greedily replaces c.Input with the first smaller candidate that still
+// fails, until no candidate fails.
+func shrink(p Property, c *Counterexample) {
+	if p.Shrink == nil {
+		return
+	}
+	for {
+		smaller := false
+		for _, cand := range p.Shrink(c.Input) {
+			ref, opt := run(p.Reference, cand), run(p.Optimized, cand)
+			if !ref.agrees(opt) {
+				c.Input, c.Reference, c.Optimized = cand, ref, opt
+				c.Shrinks++
+				smaller = true
+				break
+			}
+		}
+		if !smaller {
+			return
+		}
+	}
+}
+
+// Main is the entry point for a day's "diff" command. It parses -n and -seed
+// from args, checks every property and exits non-zero if any of them failed.
+func Main(args []string, props ...Property) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	trials := fs.Int("n", 1000, "number of random inputs per property")
+	seed := fs.Int64("seed", time.Now().UnixNano(), "random seed")
+	fs.Parse(args)
+
+	failed := false
+	for _, p := range props {
+		if c := Check(p, *seed, *trials); c != nil {
+			fmt.Println(c)
+			failed = true
+			continue
+		}
+		fmt.Printf("%s: ok, %d trials (seed %d)\n", p.Name, *trials, *seed)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// ShrinkInts returns smaller versions of vals: each one with a single element
+// removed, then each one with a single element moved halfway towards zero.
+func ShrinkInts(vals []int) [][]int {
+	var out [][]int
+	for i := range vals {
+		smaller := make([]int, 0, len(vals)-1)
+		smaller = append(smaller, vals[:i]...)
+		smaller = append(smaller, vals[i+1:]...)
+		out = append(out, smaller)
+	}
+	for i, v := range vals {
+		if v == 0 {
+			continue
+		}
+		smaller := append([]int(nil), vals...)
+		smaller[i] = v / 2
+		out = append(out, smaller)
+	}
+	return out
+}
+
+// ShrinkStrings returns vals with each single element removed in turn.
+func ShrinkStrings(vals []string) [][]string {
+	var out [][]string
+	for i := range vals {
+		smaller := make([]string, 0, len(vals)-1)
+		smaller = append(smaller, vals[:i]...)
+		smaller = append(smaller, vals[i+1:]...)
+		out = append(out, smaller)
+	}
+	return out
+}
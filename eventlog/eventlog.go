@@ -0,0 +1,184 @@
+// Package eventlog records what a solver did as a stream of events, one JSON
+// object per line, so that a run can be inspected or compared with another run
+// after the fact.
+//
+// Logs are deterministic: events carry a sequence number rather than a time,
+// and fields are written in sorted order, so two runs over the same input
+// produce identical logs.
+package eventlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Fields holds the machine-readable details of an event.
+type Fields map[string]interface{}
+
+// Event is one line of a log.
+type Event struct {
+	Seq    int    `json:"seq"`
+	Kind   string `json:"kind"`
+	Msg    string `json:"msg"`
+	Fields Fields `json:"fields,omitempty"`
+}
+
+func (e Event) String() string {
+	return fmt.Sprintf("%d %s: %s", e.Seq, e.Kind, e.Msg)
+}
+
+// Log writes events. A nil *Log discards everything, so solvers can emit
+// events unconditionally.
+type Log struct {
+	w   *bufio.Writer
+	c   io.Closer
+	enc *json.Encoder
+	seq int
+	err error
+	// name, if set, is the flag holding the file to open on first use
+	name *string
+}
+
+// New returns a Log that writes to w.
+func New(w io.Writer) *Log {
+	bw := bufio.NewWriter(w)
+	return &Log{
+		w:   bw,
+		enc: json.NewEncoder(bw),
+	}
+}
+
+// Create returns a Log that writes to the named file. An empty name returns a
+// nil Log, which discards events.
+func Create(name string) (*Log, error) {
+	if name == "" {
+		return nil, nil
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	l := New(f)
+	l.c = f
+	return l, nil
+}
+
+// FromFlag registers an -events flag naming a file to log to, and returns the
+// Log for it. The file is created when the log is first used, after the flags
+// have been parsed; until then, or if -events is not set, the Log discards
+// everything. It is meant to initialise a package level variable:
+//
+//	var events = eventlog.FromFlag()
+func FromFlag() *Log {
+	return &Log{
+		name: flag.String("events", "", "write a log of solver events to this file"),
+	}
+}
+
+// Enabled reports whether events are being recorded. Callers in hot loops can
+// check it before building the fields of an event.
+func (l *Log) Enabled() bool {
+	return l != nil && (l.name == nil || *l.name != "")
+}
+
+// open creates the file of a Log from FromFlag, if it hasn't been already, and
+// reports whether the log can be written.
+func (l *Log) open() bool {
+	if l.err != nil {
+		return false
+	}
+	if l.w != nil {
+		return true
+	}
+	f, err := os.Create(*l.name)
+	if err != nil {
+		l.err = err
+		return false
+	}
+	l.w = bufio.NewWriter(f)
+	l.c = f
+	l.enc = json.NewEncoder(l.w)
+	return true
+}
+
+// Emit records an event. The message is built from format and args, and should
+// read on its own, such as "board 3 marked (2,4)".
+func (l *Log) Emit(kind string, fields Fields, format string, args ...interface{}) {
+	if !l.Enabled() || !l.open() {
+		return
+	}
+	l.seq++
+	l.err = l.enc.Encode(Event{
+		Seq:    l.seq,
+		Kind:   kind,
+		Msg:    fmt.Sprintf(format, args...),
+		Fields: fields,
+	})
+}
+
+// Close flushes the log, closes the underlying file if Create opened it, and
+// returns the first error seen while writing.
+func (l *Log) Close() error {
+	if !l.Enabled() {
+		return nil
+	}
+	// a log from FromFlag that was never used still leaves an empty file
+	if !l.open() {
+		return l.err
+	}
+	if err := l.w.Flush(); err != nil && l.err == nil {
+		l.err = err
+	}
+	if l.c != nil {
+		if err := l.c.Close(); err != nil && l.err == nil {
+			l.err = err
+		}
+	}
+	return l.err
+}
+
+// MustClose closes the log and panics if it couldn't be written, for deferring
+// from main.
+func (l *Log) MustClose() {
+	if err := l.Close(); err != nil {
+		panic(err)
+	}
+}
+
+// Read parses a log written by a Log.
+func Read(r io.Reader) ([]Event, error) {
+	var out []Event
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		out = append(out, e)
+	}
+	return out, scanner.Err()
+}
+
+// ReadFile parses the named log.
+func ReadFile(name string) ([]Event, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	events, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return events, nil
+}
@@ -0,0 +1,114 @@
+package eventlog
+
+import "strings"
+
+// Filter returns the events whose kind is one of kinds and whose message
+// contains text. An empty kinds or text matches everything.
+func Filter(events []Event, kinds []string, text string) []Event {
+	var out []Event
+	for _, e := range events {
+		if len(kinds) > 0 && !contains(kinds, e.Kind) {
+			continue
+		}
+		if !strings.Contains(e.Msg, text) {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func contains(vals []string, s string) bool {
+	for _, v := range vals {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Op says whether an event in a Change is in both logs, only the first, or
+// only the second.
+type Op byte
+
+const (
+	Same    Op = ' '
+	Removed Op = '-'
+	Added   Op = '+'
+)
+
+// Change is one event in the difference between two logs.
+type Change struct {
+	Op    Op
+	Event Event
+}
+
+// maxDiffCells bounds the table used to line up the two logs. Past it, the
+// differing middle sections are reported as wholly removed and added.
+const maxDiffCells = 16 * 1024 * 1024
+
+// Diff compares two logs by kind and message. Sequence numbers are ignored, so
+// one extra event early on doesn't make every later event differ.
+func Diff(a, b []Event) []Change {
+	same := func(i, j int) bool {
+		return a[i].Kind == b[j].Kind && a[i].Msg == b[j].Msg
+	}
+	var out []Change
+	// the common prefix and suffix are usually most of the log
+	start := 0
+	for start < len(a) && start < len(b) && same(start, start) {
+		out = append(out, Change{Same, a[start]})
+		start++
+	}
+	endA, endB := len(a), len(b)
+	for endA > start && endB > start && same(endA-1, endB-1) {
+		endA--
+		endB--
+	}
+	n, m := endA-start, endB-start
+	if n*m > maxDiffCells {
+		for i := start; i < endA; i++ {
+			out = append(out, Change{Removed, a[i]})
+		}
+		for j := start; j < endB; j++ {
+			out = append(out, Change{Added, b[j]})
+		}
+	} else {
+		// lcs[i][j] is the longest common subsequence of a[start+i:endA] and b[start+j:endB]
+		lcs := make([][]int, n+1)
+		for i := range lcs {
+			lcs[i] = make([]int, m+1)
+		}
+		for i := n - 1; i >= 0; i-- {
+			for j := m - 1; j >= 0; j-- {
+				switch {
+				case same(start+i, start+j):
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				case lcs[i+1][j] >= lcs[i][j+1]:
+					lcs[i][j] = lcs[i+1][j]
+				default:
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < n || j < m {
+			switch {
+			case i < n && j < m && same(start+i, start+j):
+				out = append(out, Change{Same, a[start+i]})
+				i++
+				j++
+			case j == m || (i < n && lcs[i+1][j] >= lcs[i][j+1]):
+				out = append(out, Change{Removed, a[start+i]})
+				i++
+			default:
+				out = append(out, Change{Added, b[start+j]})
+				j++
+			}
+		}
+	}
+	for i := endA; i < len(a); i++ {
+		out = append(out, Change{Same, a[i]})
+	}
+	return out
+}
@@ -0,0 +1,90 @@
+// Package input reads puzzle input files a piece at a time, so that generated
+// inputs far larger than the puzzle's own can be streamed rather than having
+// to fit in a scanner's buffer.
+//
+// Every day reads its input through Lines or Commas, which share the -maxline
+// and -maxtoken flags, and a scanner that stops on a piece longer than the
+// limit says which flag to raise.
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+)
+
+// maxLine is the longest input line Lines will accept. bufio's default of 64KB
+// is too small for some generated inputs, so it can be raised with -maxline.
+var maxLine = flag.Int("maxline", 1024*1024, "longest input line to accept, in bytes")
+
+// maxToken is the longest single value Commas will accept. The list itself can
+// be any length, as it is read a value at a time.
+var maxToken = flag.Int("maxtoken", 64*1024, "longest single value to accept, in bytes")
+
+// Scanner is a bufio.Scanner whose Err names the file it was reading and, when
+// a piece was too long, the flag that allows a longer one.
+type Scanner struct {
+	*bufio.Scanner
+	name  string
+	piece string
+	flag  string
+	limit int
+}
+
+// Lines returns a Scanner over the lines of r, which was opened from the file
+// name.
+func Lines(r io.Reader, name string) *Scanner {
+	s := &Scanner{
+		Scanner: bufio.NewScanner(r),
+		name:    name,
+		piece:   "line",
+		flag:    "-maxline",
+		limit:   *maxLine,
+	}
+	s.Buffer(nil, s.limit)
+	s.Split(bufio.ScanLines)
+	return s
+}
+
+// Commas returns a Scanner over the comma separated values of r, which was
+// opened from the file name. Values have surrounding whitespace trimmed.
+func Commas(r io.Reader, name string) *Scanner {
+	s := &Scanner{
+		Scanner: bufio.NewScanner(r),
+		name:    name,
+		piece:   "value",
+		flag:    "-maxtoken",
+		limit:   *maxToken,
+	}
+	s.Buffer(nil, s.limit)
+	s.Split(ScanCommas)
+	return s
+}
+
+// Err returns the first error the scanner met, if any, prefixed with the name
+// of the file.
+func (s *Scanner) Err() error {
+	err := s.Scanner.Err()
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, bufio.ErrTooLong):
+		return fmt.Errorf("%s: %s longer than %d bytes, rerun with a larger %s: %w", s.name, s.piece, s.limit, s.flag, err)
+	}
+	return fmt.Errorf("%s: %w", s.name, err)
+}
+
+// ScanCommas is a bufio.SplitFunc that returns each comma separated value in
+// turn, so a very long list never has to fit in the scanner's buffer.
+func ScanCommas(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexByte(data, ','); i >= 0 {
+		return i + 1, bytes.TrimSpace(data[:i]), nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), bytes.TrimSpace(data), nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"advent-2021/eventlog"
+)
+
+/*
+logview reads the event logs that the days write with -events.
+
+	go run ./logview show [-kind k1,k2] [-grep text] run.jsonl
+	go run ./logview diff [-kind k1,k2] [-grep text] [-context n] before.jsonl after.jsonl
+
+show prints the events that match the filters. diff lines up two filtered logs
+and prints the events that are only in the first (-) or only in the second (+),
+with n events of context around each difference.
+*/
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	fs := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	kind := fs.String("kind", "", "comma separated event kinds to keep")
+	grep := fs.String("grep", "", "only keep events whose message contains this text")
+	context := fs.Int("context", 2, "events of context to show around differences")
+	fs.Parse(os.Args[2:])
+
+	var kinds []string
+	if *kind != "" {
+		kinds = strings.Split(*kind, ",")
+	}
+	load := func(name string) []eventlog.Event {
+		events, err := eventlog.ReadFile(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return eventlog.Filter(events, kinds, *grep)
+	}
+
+	switch os.Args[1] {
+	case "show":
+		if fs.NArg() != 1 {
+			usage()
+		}
+		for _, e := range load(fs.Arg(0)) {
+			fmt.Println(e)
+		}
+	case "diff":
+		if fs.NArg() != 2 {
+			usage()
+		}
+		changes := eventlog.Diff(load(fs.Arg(0)), load(fs.Arg(1)))
+		if !printDiff(changes, *context) {
+			os.Exit(1)
+		}
+	default:
+		usage()
+	}
+}
+
+// printDiff prints the changes with context around them, and reports whether
+// the logs were the same.
+func printDiff(changes []eventlog.Change, context int) bool {
+	// keep[i] is true for changes and the context around them
+	keep := make([]bool, len(changes))
+	var removed, added int
+	for i, c := range changes {
+		if c.Op == eventlog.Same {
+			continue
+		}
+		if c.Op == eventlog.Removed {
+			removed++
+		} else {
+			added++
+		}
+		for j := i - context; j <= i+context; j++ {
+			if j >= 0 && j < len(changes) {
+				keep[j] = true
+			}
+		}
+	}
+	if removed == 0 && added == 0 {
+		fmt.Println("logs match,", len(changes), "events")
+		return true
+	}
+	skipped := false
+	for i, c := range changes {
+		if !keep[i] {
+			skipped = true
+			continue
+		}
+		if skipped {
+			fmt.Println("...")
+			skipped = false
+		}
+		fmt.Printf("%c %s\n", c.Op, c.Event)
+	}
+	fmt.Printf("%d removed, %d added\n", removed, added)
+	return false
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: logview show|diff [-kind k1,k2] [-grep text] [-context n] log [log]")
+	os.Exit(2)
+}
@@ -0,0 +1,161 @@
+// Package repl lets a puzzle simulation be stepped through by hand instead of
+// being run to completion.
+//
+// A day wraps its own state in a Sim and hands it to Run, which reads commands
+// from the user:
+//
+//	step [n]   advance n steps (default 1)
+//	show       print the current state
+//	set ...    change the state; the arguments are up to the day
+//	undo       take back the last step, set or goto
+//	goto n     move forwards or backwards to step n
+//	help       list the commands
+//	quit       leave
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrDone is returned by Sim.Step when the simulation has nothing left to do,
+// such as a bingo game with no more numbers to draw.
+var ErrDone = errors.New("simulation finished")
+
+// Sim is a simulation that can be advanced one step at a time.
+type Sim interface {
+	// Step advances the simulation by one step.
+	Step() error
+	// Show prints the current state.
+	Show(w io.Writer)
+	// Set changes the current state. Usage describes the arguments it takes.
+	Set(args []string) error
+	Usage() string
+	// Snapshot returns a copy of the current state that can later be passed to
+	// Restore. It must not share any mutable data with the simulation.
+	Snapshot() interface{}
+	Restore(state interface{})
+}
+
+type frame struct {
+	step  int
+	state interface{}
+}
+
+type session struct {
+	sim  Sim
+	out  io.Writer
+	step int
+	// seen holds the state after each step that has been reached, for goto
+	seen []interface{}
+	undo []frame
+}
+
+// Run reads commands from in until it is exhausted or the user quits, writing
+// prompts and output to out.
+func Run(name string, sim Sim, in io.Reader, out io.Writer) error {
+	s := &session{
+		sim:  sim,
+		out:  out,
+		seen: []interface{}{sim.Snapshot()},
+	}
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(out, "%s [step %d]> ", name, s.step)
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "quit" || fields[0] == "exit" {
+			return nil
+		}
+		if err := s.command(fields[0], fields[1:]); err != nil {
+			fmt.Fprintln(out, "error:", err)
+		}
+	}
+}
+
+func (s *session) command(cmd string, args []string) error {
+	switch cmd {
+	case "step", "s":
+		n := 1
+		if len(args) > 0 {
+			var err error
+			n, err = strconv.Atoi(args[0])
+			if err != nil || n < 1 {
+				return fmt.Errorf("bad step count %q", args[0])
+			}
+		}
+		s.save()
+		return s.forward(n)
+	case "show":
+		s.sim.Show(s.out)
+	case "set":
+		before := s.sim.Snapshot()
+		if err := s.sim.Set(args); err != nil {
+			return fmt.Errorf("%w\nusage: set %s", err, s.sim.Usage())
+		}
+		s.undo = append(s.undo, frame{step: s.step, state: before})
+		// later steps no longer follow from this state
+		s.seen = append(s.seen[:s.step], s.sim.Snapshot())
+	case "undo":
+		if len(s.undo) == 0 {
+			return errors.New("nothing to undo")
+		}
+		last := s.undo[len(s.undo)-1]
+		s.undo = s.undo[:len(s.undo)-1]
+		s.sim.Restore(last.state)
+		s.step = last.step
+		s.seen = append(s.seen[:s.step], s.sim.Snapshot())
+	case "goto", "g":
+		if len(args) != 1 {
+			return errors.New("usage: goto n")
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 {
+			return fmt.Errorf("bad step %q", args[0])
+		}
+		s.save()
+		if n < len(s.seen) {
+			s.sim.Restore(s.seen[n])
+			s.step = n
+			return nil
+		}
+		s.sim.Restore(s.seen[len(s.seen)-1])
+		s.step = len(s.seen) - 1
+		return s.forward(n - s.step)
+	case "help", "?":
+		fmt.Fprintln(s.out, "commands: step [n], show, set "+s.sim.Usage()+", undo, goto n, quit")
+	default:
+		return fmt.Errorf("unknown command %q, try help", cmd)
+	}
+	return nil
+}
+
+// save records the current state so that the next command can be undone.
+func (s *session) save() {
+	s.undo = append(s.undo, frame{step: s.step, state: s.sim.Snapshot()})
+}
+
+func (s *session) forward(n int) error {
+	for i := 0; i < n; i++ {
+		if err := s.sim.Step(); err != nil {
+			return fmt.Errorf("step %d: %w", s.step+1, err)
+		}
+		s.step++
+		if s.step < len(s.seen) {
+			s.seen[s.step] = s.sim.Snapshot()
+		} else {
+			s.seen = append(s.seen, s.sim.Snapshot())
+		}
+	}
+	return nil
+}